@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/gofiber/fiber/v3"
@@ -10,7 +11,9 @@ import (
 	"github.com/gofiber/fiber/v3/middleware/recover"
 	"github.com/l-fraga2811/back-sable/internal/config"
 	"github.com/l-fraga2811/back-sable/internal/handlers"
+	"github.com/l-fraga2811/back-sable/internal/jobs"
 	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/google"
 	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
 	"github.com/l-fraga2811/back-sable/internal/routes"
 )
@@ -26,30 +29,94 @@ func main() {
 	// Initialize GORM Repository
 	itemRepo := repository.NewItemRepositoryGORM(cfg.DB)
 	profileRepo := repository.NewProfileRepositoryGorm(cfg.DB)
+	revisionRepo := repository.NewItemRevisionRepositoryGorm(cfg.DB)
+	shareRepo := repository.NewItemShareRepositoryGorm(cfg.DB)
+	attachmentRepo := repository.NewItemAttachmentRepositoryGorm(cfg.DB)
+	tagRepo := repository.NewTagRepositoryGorm(cfg.DB)
+	apiKeyRepo := repository.NewAPIKeyRepositoryGorm(cfg.DB)
+
+	// Initialize Blob Store for attachments
+	var blobStore repository.BlobStore
+	var localBlobs *repository.LocalBlobStore
+	switch cfg.BlobStore {
+	case "supabase":
+		store, err := supabase.NewStorageBlobStore(supabaseClient, cfg.BlobBucket)
+		if err != nil {
+			log.Fatal("Failed to initialize blob store (BLOB_STORE=supabase): ", err)
+		}
+		blobStore = store
+	default:
+		store, err := repository.NewLocalBlobStore(cfg.BlobLocalDir, cfg.PublicBaseURL, cfg.BlobSigningKey)
+		if err != nil {
+			log.Fatal("Failed to initialize blob store:", err)
+		}
+		blobStore, localBlobs = store, store
+	}
+
+	// Start Background Jobs
+	tokenValidator.StartKeyRefresh(context.Background())
+	jobs.NewTrashPurger(itemRepo, cfg.TrashRetention, cfg.TrashPurgeInterval).
+		WithBlobStore(blobStore).
+		Start(context.Background())
+
+	revocationStore := repository.NewMemoryRevocationStore()
 
 	// Initialize Global Auth Handlers
-	handlers.InitAuthHandlers(handlers.NewAuthHandlerWithProfileRepo(supabaseClient, profileRepo))
+	authHandler := handlers.NewAuthHandlerWithProfileRepo(supabaseClient, profileRepo).
+		WithRevocationStore(revocationStore).
+		WithGoogleOAuth(google.NewOAuthClient(cfg), repository.NewMemoryOAuthStateStore()).
+		WithAvatarStorage(cfg.AvatarBucket, cfg.AvatarPublic, cfg.AvatarSignedURLTTL)
+	handlers.InitAuthHandlers(authHandler)
 
 	// Initialize Handlers
-	itemHandler := handlers.NewItemHandler(itemRepo)
+	itemHandler := handlers.NewItemHandler(itemRepo, revisionRepo, shareRepo).
+		WithAttachments(attachmentRepo, blobStore).
+		WithProfiles(profileRepo)
+	tagHandler := handlers.NewTagHandler(tagRepo, itemRepo, shareRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo)
 	healthHandler := handlers.NewHealthHandler()
 
 	// Initialize Fiber
-	app := fiber.New(fiber.Config{
+	fiberConfig := fiber.Config{
 		AppName: "Sable Backend",
-	})
+	}
+	// Deployed behind a load balancer at one of TRUSTED_PROXIES that puts
+	// the client address in PROXY_HEADER: c.IP(), and with it the per-IP
+	// rate limits, reads the header only on requests from those addresses.
+	// The proxy must overwrite the header rather than append to one sent by
+	// the client, since the first address in it is used. Fiber believes
+	// ProxyHeader from anyone while TrustProxy is off, so it is only set
+	// together with the allowlist.
+	if len(cfg.TrustedProxies) > 0 {
+		fiberConfig.TrustProxy = true
+		fiberConfig.TrustProxyConfig = fiber.TrustProxyConfig{Proxies: cfg.TrustedProxies}
+		fiberConfig.ProxyHeader = cfg.ProxyHeader
+		fiberConfig.EnableIPValidation = true
+	}
+	app := fiber.New(fiberConfig)
 
 	// Middleware
 	app.Use(logger.New())
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowOrigins:  []string{"*"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		ExposeHeaders: []string{"ETag"},
 	}))
 
 	// Setup Routes
-	routes.SetupRoutes(app, tokenValidator, itemHandler, nil, healthHandler)
+	routes.SetupRoutes(app, routes.Dependencies{
+		TokenValidator: tokenValidator,
+		Revocations:    revocationStore,
+		APIKeys:        apiKeyRepo,
+		Profiles:       profileRepo,
+		LocalBlobs:     localBlobs,
+		ItemHandler:    itemHandler,
+		TagHandler:     tagHandler,
+		APIKeyHandler:  apiKeyHandler,
+		HealthHandler:  healthHandler,
+	})
 
 	// Start Server
 	log.Printf("Server starting on port %s", cfg.Port)
@@ -0,0 +1,75 @@
+// cmd/backfill-profiles/main.go
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/l-fraga2811/back-sable/internal/config"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// Cria as linhas de profiles que faltam para usuários do Supabase, a partir
+// do user_metadata. Perfis existentes não são alterados, então pode rodar
+// quantas vezes for preciso.
+func main() {
+	perPage := flag.Int("per-page", 100, "users fetched per page")
+	dryRun := flag.Bool("dry-run", false, "only report missing profiles")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	client := supabase.NewClient(cfg)
+	profileRepo := repository.NewProfileRepositoryGorm(cfg.DB)
+
+	ctx := context.Background()
+	var scanned, created, failed int
+
+	for page := 1; ; page++ {
+		users, err := client.ListUsers(ctx, page, *perPage)
+		if err != nil {
+			log.Fatal("Erro ao listar usuários:", err)
+		}
+
+		for _, user := range users {
+			scanned++
+			id, err := uuid.Parse(user.ID)
+			if err != nil {
+				log.Printf("Ignorando usuário com id inválido %q", user.ID)
+				continue
+			}
+
+			if *dryRun {
+				existing, err := profileRepo.GetByID(user.ID)
+				if err != nil {
+					failed++
+					log.Printf("Erro ao consultar perfil %s: %v", user.ID, err)
+				} else if existing == nil {
+					created++
+					log.Printf("Perfil ausente: %s", user.ID)
+				}
+				continue
+			}
+
+			profile := models.ProfileFromMetadata(id, user.UserMetadata)
+			ok, err := profileRepo.Provision(&profile)
+			if err != nil {
+				failed++
+				log.Printf("Erro ao criar perfil %s: %v", user.ID, err)
+				continue
+			}
+			if ok {
+				created++
+			}
+		}
+
+		if len(users) < *perPage {
+			break
+		}
+	}
+
+	log.Printf("Backfill concluído: %d usuários, %d perfis criados, %d falhas (dry-run: %t)", scanned, created, failed, *dryRun)
+}
@@ -7,17 +7,49 @@ import (
     "github.com/l-fraga2811/back-sable/internal/models"
 )
 
+// sqlMigrations são executadas após o AutoMigrate, para o que o GORM não
+// consegue expressar. Todas precisam ser idempotentes.
+var sqlMigrations = []string{
+    // Busca textual: tsvector gerado a partir de título e descrição + índice GIN
+    `ALTER TABLE items ADD COLUMN IF NOT EXISTS search_vector tsvector
+        GENERATED ALWAYS AS (
+            setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+            setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+        ) STORED`,
+    `CREATE INDEX IF NOT EXISTS idx_items_search_vector ON items USING GIN (search_vector)`,
+    // Nomes de tag únicos por usuário sem diferenciar maiúsculas, como NameTaken
+    `CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name_lower ON tags (user_id, lower(name))`,
+    // Usernames únicos sem diferenciar maiúsculas; a tabela profiles é do Supabase
+    `DO $$ BEGIN
+        IF to_regclass('profiles') IS NOT NULL THEN
+            CREATE UNIQUE INDEX IF NOT EXISTS idx_profiles_username_lower
+                ON profiles (lower(username)) WHERE username <> '';
+        END IF;
+    END $$`,
+}
+
 func main() {
     cfg := config.LoadConfig()
 
     // Executar auto-migration
     err := cfg.DB.AutoMigrate(
+        &models.Tag{},
         &models.Item{},
+        &models.ItemRevision{},
+        &models.ItemShare{},
+        &models.APIKey{},
+        &models.ItemAttachment{},
     )
 
     if err != nil {
         log.Fatal("Erro na migração:", err)
     }
 
+    for _, stmt := range sqlMigrations {
+        if err := cfg.DB.Exec(stmt).Error; err != nil {
+            log.Fatal("Erro na migração:", err)
+        }
+    }
+
     log.Println("Migração concluída com sucesso!")
 }
\ No newline at end of file
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
@@ -19,6 +22,50 @@ type Config struct {
 	GoogleClientID string
 	GoogleSecret   string
 	DB             *gorm.DB
+
+	// SupabaseServiceRoleKey is used by admin tooling such as the profile
+	// backfill and, with BlobStore "supabase", by the API to store
+	// attachments. It bypasses row level security; never expose it.
+	SupabaseServiceRoleKey string
+
+	// JwtIssuers and JwtAudiences are the accepted iss and aud values of
+	// access tokens; JwtLeeway is the clock skew tolerated on exp, nbf and
+	// iat.
+	JwtIssuers   []string
+	JwtAudiences []string
+	JwtLeeway    time.Duration
+
+	// GoogleRedirectURL is the OAuth callback registered with Google, e.g.
+	// https://api.example.com/api/auth/oauth/google/callback.
+	GoogleRedirectURL string
+
+	// AvatarBucket is the Supabase Storage bucket avatars are uploaded to.
+	// A private bucket (AvatarPublic false) is linked with signed URLs that
+	// expire after AvatarSignedURLTTL.
+	AvatarBucket       string
+	AvatarPublic       bool
+	AvatarSignedURLTTL time.Duration
+
+	// BlobStore selects where attachments are stored: "local" keeps them in
+	// BlobLocalDir and signs download links with BlobSigningKey against
+	// PublicBaseURL; "supabase" uses the private BlobBucket and needs the
+	// service role key.
+	BlobStore      string
+	BlobLocalDir   string
+	BlobBucket     string
+	BlobSigningKey string
+	PublicBaseURL  string
+
+	// TrustedProxies lists the load balancer addresses or CIDR ranges whose
+	// ProxyHeader is believed for the client IP (rate limits, logs). Left
+	// empty, the TCP peer address is used and the header is ignored.
+	TrustedProxies []string
+	ProxyHeader    string
+
+	// TrashRetention is how long soft-deleted items are kept before the
+	// purger removes them for good. Zero disables purging.
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
 }
 
 func LoadConfig() *Config {
@@ -32,6 +79,16 @@ func LoadConfig() *Config {
 		jwksURL = getEnv("NEXT_PUBLIC_SUPABASE_JWKS_URL", "")
 	}
 
+	supabaseURL := getEnv("SUPABASE_URL", "")
+	jwtIssuers := getEnvList("SUPABASE_JWT_ISSUER")
+	if len(jwtIssuers) == 0 && supabaseURL != "" {
+		jwtIssuers = []string{strings.TrimRight(supabaseURL, "/") + "/auth/v1"}
+	}
+	jwtAudiences := getEnvList("SUPABASE_JWT_AUDIENCE")
+	if len(jwtAudiences) == 0 {
+		jwtAudiences = []string{"authenticated"}
+	}
+
 	// Initialize database connection
 	db, err := NewGormDB()
 	if err != nil {
@@ -40,13 +97,37 @@ func LoadConfig() *Config {
 
 	return &Config{
 		Port:           getEnv("PORT", ""),
-		SupabaseURL:    getEnv("SUPABASE_URL", ""),
+		SupabaseURL:    supabaseURL,
 		SupabaseKey:    getEnv("SUPABASE_KEY", ""),
 		JwksURL:        jwksURL,
 		JwtSecret:      getEnv("SUPABASE_JWT_SECRET", ""),
 		GoogleClientID: getEnv("GOOGLE_CLIENT_ID", ""),
 		GoogleSecret:   getEnv("GOOGLE_CLIENT_SECRET", ""),
 		DB:             db,
+
+		SupabaseServiceRoleKey: getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
+
+		JwtIssuers:   jwtIssuers,
+		JwtAudiences: jwtAudiences,
+		JwtLeeway:    getEnvDuration("SUPABASE_JWT_LEEWAY", 30*time.Second),
+
+		GoogleRedirectURL: getEnv("GOOGLE_REDIRECT_URL", ""),
+
+		AvatarBucket:       getEnv("SUPABASE_AVATAR_BUCKET", "avatars"),
+		AvatarPublic:       getEnv("SUPABASE_AVATAR_PUBLIC", "true") == "true",
+		AvatarSignedURLTTL: time.Duration(getEnvInt("SUPABASE_AVATAR_URL_TTL_DAYS", 30)) * 24 * time.Hour,
+
+		BlobStore:      getEnv("BLOB_STORE", "local"),
+		BlobLocalDir:   getEnv("BLOB_LOCAL_DIR", "./data/blobs"),
+		BlobBucket:     getEnv("BLOB_BUCKET", "attachments"),
+		BlobSigningKey: getEnv("BLOB_SIGNING_KEY", ""),
+		PublicBaseURL:  getEnv("PUBLIC_BASE_URL", "http://localhost:"+getEnv("PORT", "3000")),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+		ProxyHeader:    getEnv("PROXY_HEADER", "X-Forwarded-For"),
+
+		TrashRetention:     time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		TrashPurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
 	}
 }
 
@@ -57,6 +138,43 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %d", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %s", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
 func NewGormDB() (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=require TimeZone=America/Sao_Paulo",
 		os.Getenv("SUPABASE_DB_HOST"),
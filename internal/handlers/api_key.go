@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+type APIKeyHandler struct {
+	apiKeyRepo repository.APIKeyRepository
+}
+
+func NewAPIKeyHandler(apiKeyRepo repository.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyRepo: apiKeyRepo}
+}
+
+// createdAPIKeyResponse is the only response that includes the secret; it
+// cannot be retrieved again.
+type createdAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+func (h *APIKeyHandler) GetAll(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	keys, err := h.apiKeyRepo.ListByUser(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching API keys"})
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	return c.JSON(keys)
+}
+
+func (h *APIKeyHandler) Create(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+	}
+	req.Normalize()
+	if err := req.Validate(time.Now()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+	}
+
+	secret, prefix, err := models.NewAPIKeySecret()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error creating API key"})
+	}
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   models.HashAPIKey(secret),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt.UTC(),
+	}
+	if err := h.apiKeyRepo.Create(key); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error creating API key"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(createdAPIKeyResponse{APIKey: *key, Key: secret})
+}
+
+func (h *APIKeyHandler) Revoke(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	revoked, err := h.apiKeyRepo.Revoke(c.Params("id"), userID, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error revoking API key"})
+	}
+	if !revoked {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "API key not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "API key revoked"})
+}
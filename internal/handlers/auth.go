@@ -1,11 +1,16 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
+	"log"
+	"net/mail"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/l-fraga2811/back-sable/internal/models"
 	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/google"
 	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
 )
 
@@ -33,6 +38,114 @@ func SignUp(c fiber.Ctx) error {
 	return globalAuthHandler.Register(c)
 }
 
+func RefreshSession(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.Refresh(c)
+}
+
+func SignOut(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.Logout(c)
+}
+
+func RecoverPassword(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.RecoverPassword(c)
+}
+
+func ChangePassword(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.ChangePassword(c)
+}
+
+func RequestOTP(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.RequestOTP(c)
+}
+
+func VerifyOTP(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.VerifyOTP(c)
+}
+
+func GoogleOAuthStart(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.GoogleOAuthStart(c)
+}
+
+func GoogleOAuthCallback(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.GoogleOAuthCallback(c)
+}
+
+func EnrollMFAFactor(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.EnrollMFA(c)
+}
+
+func ChallengeMFAFactor(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.ChallengeMFA(c)
+}
+
+func VerifyMFAFactor(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.VerifyMFA(c)
+}
+
+func UnenrollMFAFactor(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.UnenrollMFA(c)
+}
+
 func GetProfile(c fiber.Ctx) error {
 	if globalAuthHandler == nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -42,9 +155,43 @@ func GetProfile(c fiber.Ctx) error {
 	return globalAuthHandler.GetProfile(c)
 }
 
+func UpdateProfile(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.UpdateProfile(c)
+}
+
+func PatchProfile(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.PatchProfile(c)
+}
+
+func UploadAvatar(c fiber.Ctx) error {
+	if globalAuthHandler == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Auth handler not initialized",
+		})
+	}
+	return globalAuthHandler.UploadAvatar(c)
+}
+
 type AuthHandler struct {
 	client      *supabase.Client
 	profileRepo repository.ProfileRepository
+	revocations repository.TokenRevocationStore
+	google      *google.OAuthClient
+	oauthStates repository.OAuthStateStore
+
+	avatarBucket string
+	avatarPublic bool
+	avatarURLTTL time.Duration
 }
 
 func NewAuthHandler(client *supabase.Client) *AuthHandler {
@@ -60,11 +207,67 @@ func NewAuthHandlerWithProfileRepo(client *supabase.Client, profileRepo reposito
 	}
 }
 
+// WithRevocationStore makes Logout record signed-out tokens in store so the
+// auth middleware rejects them before they expire.
+func (h *AuthHandler) WithRevocationStore(store repository.TokenRevocationStore) *AuthHandler {
+	h.revocations = store
+	return h
+}
+
+// defaultTokenLifetime bounds how long revocations are kept for tokens that
+// do not carry both iat and exp.
+const defaultTokenLifetime = time.Hour
+
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type recoverRequest struct {
+	Email      string `json:"email"`
+	RedirectTo string `json:"redirectTo"`
+}
+
+type changePasswordRequest struct {
+	Password string `json:"password"`
+	// Nonce is the reauthentication code Supabase emails when the project
+	// requires a recent sign-in to change passwords.
+	Nonce string `json:"nonce"`
+}
+
+type otpRequest struct {
+	Email      string `json:"email"`
+	Phone      string `json:"phone"`
+	Channel    string `json:"channel"`
+	CreateUser bool   `json:"createUser"`
+	RedirectTo string `json:"redirectTo"`
+}
+
+type verifyOTPRequest struct {
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	Token     string `json:"token"`
+	TokenHash string `json:"tokenHash"`
+	Type      string `json:"type"`
+}
+
+// otpVerifyTypes are the Supabase verification types that produce a session.
+var otpVerifyTypes = map[string]bool{
+	"email":     true,
+	"sms":       true,
+	"magiclink": true,
+	"signup":    true,
+	"invite":    true,
+	"recovery":  true,
+}
+
+// minPasswordLength matches Supabase's default minimum.
+const minPasswordLength = 6
+
 type registerRequest struct {
 	Username   string `json:"username"`
 	Email      string `json:"email"`
@@ -82,10 +285,49 @@ type userResponse struct {
 }
 
 type authResponse struct {
-	Message   string       `json:"message"`
-	Token     string       `json:"token"`
-	ExpiresAt string       `json:"expiresAt"`
-	User      userResponse `json:"user"`
+	Message      string       `json:"message"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refreshToken"`
+	ExpiresAt    string       `json:"expiresAt"`
+	User         userResponse `json:"user"`
+}
+
+// newAuthResponse maps a Supabase session to the response returned by every
+// endpoint that signs a user in.
+func newAuthResponse(message string, response supabase.AuthResponse) authResponse {
+	expiresAt := ""
+	if response.ExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second).UTC().Format(time.RFC3339)
+	}
+
+	return authResponse{
+		Message:      message,
+		Token:        response.AccessToken,
+		RefreshToken: response.RefreshToken,
+		ExpiresAt:    expiresAt,
+		User: userResponse{
+			ID:         response.User.ID,
+			Username:   metadataString(response.User.UserMetadata, "username"),
+			Email:      response.User.Email,
+			Phone:      metadataString(response.User.UserMetadata, "phone"),
+			ProfileUrl: metadataString(response.User.UserMetadata, "profile_url"),
+		},
+	}
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	if metadata == nil {
+		return ""
+	}
+	value, ok := metadata[key]
+	if !ok {
+		return ""
+	}
+	str, ok := value.(string)
+	if !ok {
+		return ""
+	}
+	return str
 }
 
 func (h *AuthHandler) Login(c fiber.Ctx) error {
@@ -101,58 +343,284 @@ func (h *AuthHandler) Login(c fiber.Ctx) error {
 		Password: req.Password,
 	})
 	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(newAuthResponse("Login realizado com sucesso", response))
+}
+
+func (h *AuthHandler) Refresh(c fiber.Ctx) error {
+	var req refreshRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "refreshToken is required",
+		})
+	}
+
+	response, err := h.client.RefreshSession(c.Context(), req.RefreshToken)
+	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(newAuthResponse("Sessão renovada com sucesso", response))
+}
+
+// Logout signs the caller out of Supabase and revokes their access token
+// locally. scope=local (default) ends only the current session; scope=global
+// ends every session of the user.
+func (h *AuthHandler) Logout(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(supabase.AccessTokenClaims)
+	token, _ := c.Locals("token").(string)
+	if !ok || token == "" {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Authentication failed: " + err.Error(),
+			"error": "User not authenticated",
 		})
 	}
 
-	expiresAt := ""
-	if response.ExpiresIn > 0 {
-		expiresAt = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second).UTC().Format(time.RFC3339)
+	scope := c.Query("scope", supabase.SignOutScopeLocal)
+	if scope != supabase.SignOutScopeLocal && scope != supabase.SignOutScopeGlobal {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "scope must be local or global",
+		})
+	}
+
+	// Sign out of Supabase first: revoking the token locally before that
+	// succeeds would leave the refresh token alive and block any retry. A
+	// session that Supabase no longer knows has already been signed out
+	// elsewhere (e.g. a global logout), so only the local revocation is left.
+	if err := h.client.SignOut(c.Context(), token, scope); err != nil && !sessionAlreadyEnded(err) {
+		return respondSupabaseError(c, err)
 	}
 
-	username := ""
-	if response.User.UserMetadata != nil {
-		fmt.Println(response)
-		if usernameValue, ok := response.User.UserMetadata["username"]; ok {
-			if usernameStr, ok := usernameValue.(string); ok {
-				username = usernameStr
-			}
+	if h.revocations != nil {
+		if err := revokeClaims(h.revocations, claims, scope); err != nil {
+			log.Printf("Failed to revoke token for user %s: %v", claims.Subject, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Logout failed",
+			})
 		}
 	}
 
-	phone := ""
-	if response.User.UserMetadata != nil {
-		if phoneValue, ok := response.User.UserMetadata["phone"]; ok {
-			if phoneStr, ok := phoneValue.(string); ok {
-				phone = phoneStr
-			}
+	return c.JSON(fiber.Map{
+		"message": "Logout realizado com sucesso",
+	})
+}
+
+// sessionAlreadyEnded reports whether a sign-out failed because Supabase no
+// longer has the session, which leaves the caller signed out anyway.
+func sessionAlreadyEnded(err error) bool {
+	var apiErr *supabase.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == "session_not_found" ||
+		apiErr.Status == fiber.StatusUnauthorized ||
+		apiErr.Status == fiber.StatusForbidden
+}
+
+func revokeClaims(store repository.TokenRevocationStore, claims supabase.AccessTokenClaims, scope string) error {
+	now := time.Now()
+	expiresAt := now.Add(defaultTokenLifetime)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	if key := claims.RevocationKey(); key != "" {
+		if err := store.RevokeSession(key, expiresAt); err != nil {
+			return err
 		}
 	}
 
-	profileUrl := ""
-	if response.User.UserMetadata != nil {
-		if profileValue, ok := response.User.UserMetadata["profile_url"]; ok {
-			if profileStr, ok := profileValue.(string); ok {
-				profileUrl = profileStr
-			}
+	if scope != supabase.SignOutScopeGlobal {
+		return nil
+	}
+
+	// Other sessions hold tokens issued at other times; keep the cutoff for
+	// a full token lifetime so all of them have expired when it is dropped.
+	lifetime := defaultTokenLifetime
+	if claims.ExpiresAt != nil && claims.IssuedAt != nil {
+		lifetime = claims.ExpiresAt.Sub(claims.IssuedAt.Time)
+	}
+	// iat only has whole-second precision: round the cutoff up so tokens
+	// issued by other sessions within the same second are rejected too.
+	cutoff := now.Truncate(time.Second).Add(time.Second)
+	return store.RevokeUser(claims.Subject, cutoff, now.Add(lifetime))
+}
+
+// RecoverPassword triggers Supabase's password recovery email. It answers the
+// same way whether or not the address has an account, so it cannot be used to
+// probe for registered emails.
+func (h *AuthHandler) RecoverPassword(c fiber.Ctx) error {
+	var req recoverRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A valid email is required",
+		})
+	}
+
+	if err := h.client.SendPasswordRecovery(c.Context(), req.Email, req.RedirectTo); err != nil {
+		status, message := supabaseErrorResponse(err)
+		if status == fiber.StatusTooManyRequests || status >= fiber.StatusInternalServerError {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
 		}
+		log.Printf("Password recovery request rejected by Supabase: %v", err)
 	}
 
-	return c.JSON(authResponse{
-		Message:   "Login realizado com sucesso",
-		Token:     response.AccessToken,
-		ExpiresAt: expiresAt,
-		User: userResponse{
-			ID:         response.User.ID,
-			Username:   username,
-			Email:      response.User.Email,
-			Phone:      phone,
-			ProfileUrl: profileUrl,
-		},
+	return c.JSON(fiber.Map{
+		"message": "Se o email estiver cadastrado, você receberá um link para redefinir a senha",
 	})
 }
 
+// ChangePassword sets a new password for the authenticated user.
+func (h *AuthHandler) ChangePassword(c fiber.Ctx) error {
+	token, ok := c.Locals("token").(string)
+	if !ok || token == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	var req changePasswordRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if len(req.Password) < minPasswordLength {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Password must be at least 6 characters",
+		})
+	}
+
+	_, err := h.client.UpdateUser(c.Context(), token, supabase.UserAttributes{
+		Password: req.Password,
+		Nonce:    req.Nonce,
+	})
+	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Senha alterada com sucesso",
+	})
+}
+
+// RequestOTP sends a one-time code or magic link to an email address, or a
+// code to a phone number. Unless createUser is set, only existing users can
+// sign in this way.
+func (h *AuthHandler) RequestOTP(c fiber.Ctx) error {
+	var req otpRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if (req.Email == "") == (req.Phone == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Provide either email or phone",
+		})
+	}
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "A valid email is required",
+			})
+		}
+	}
+	if req.Channel != "" && req.Channel != "sms" && req.Channel != "whatsapp" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "channel must be sms or whatsapp",
+		})
+	}
+
+	payload := supabase.OTPRequest{
+		Email:      req.Email,
+		Phone:      req.Phone,
+		CreateUser: req.CreateUser,
+	}
+	if req.Phone != "" {
+		payload.Channel = req.Channel
+	}
+
+	// As in RecoverPassword, rejections such as "signups not allowed" for an
+	// unknown email get the same answer as a sent code, so callers cannot
+	// probe which accounts exist.
+	if err := h.client.SendOTP(c.Context(), payload, req.RedirectTo); err != nil {
+		status, message := supabaseErrorResponse(err)
+		if status == fiber.StatusTooManyRequests || status >= fiber.StatusInternalServerError {
+			return c.Status(status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+		log.Printf("OTP request rejected by Supabase: %v", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Se a conta existir, você receberá um código de acesso",
+	})
+}
+
+// VerifyOTP exchanges a one-time code, or a magic link's token hash, for a
+// session and answers like Login.
+func (h *AuthHandler) VerifyOTP(c fiber.Ctx) error {
+	var req verifyOTPRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Type == "" {
+		switch {
+		case req.TokenHash != "":
+			req.Type = "magiclink"
+		case req.Phone != "":
+			req.Type = "sms"
+		default:
+			req.Type = "email"
+		}
+	}
+	if !otpVerifyTypes[req.Type] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unsupported verification type",
+		})
+	}
+	if req.TokenHash == "" && (req.Token == "" || (req.Email == "") == (req.Phone == "")) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Provide token with either email or phone, or tokenHash",
+		})
+	}
+
+	payload := supabase.VerifyOTPRequest{
+		Type:      req.Type,
+		TokenHash: req.TokenHash,
+	}
+	if req.TokenHash == "" {
+		payload.Email = req.Email
+		payload.Phone = req.Phone
+		payload.Token = req.Token
+	}
+
+	response, err := h.client.VerifyOTP(c.Context(), payload)
+	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(newAuthResponse("Login realizado com sucesso", response))
+}
+
 func (h *AuthHandler) Register(c fiber.Ctx) error {
 	var req registerRequest
 	if err := c.Bind().Body(&req); err != nil {
@@ -171,11 +639,11 @@ func (h *AuthHandler) Register(c fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Registration failed: " + err.Error(),
-		})
+		return respondSupabaseError(c, err)
 	}
 
+	h.provisionProfile(response.User)
+
 	return c.JSON(fiber.Map{
 		"message": "Usuário criado com sucesso",
 		"user": userResponse{
@@ -188,6 +656,23 @@ func (h *AuthHandler) Register(c fiber.Ctx) error {
 	})
 }
 
+// provisionProfile creates the profile row of a newly signed-up user. Failures
+// are only logged: ProvisionProfile retries on the user's first request.
+func (h *AuthHandler) provisionProfile(user supabase.User) {
+	if h.profileRepo == nil {
+		return
+	}
+	id, err := uuid.Parse(user.ID)
+	if err != nil {
+		return
+	}
+
+	profile := models.ProfileFromMetadata(id, user.UserMetadata)
+	if _, err := h.profileRepo.Provision(&profile); err != nil {
+		log.Printf("Failed to provision profile for %s: %v", user.ID, err)
+	}
+}
+
 func (h *AuthHandler) GetProfile(c fiber.Ctx) error {
 	userID, ok := c.Locals("userID").(string)
 	if !ok || userID == "" {
@@ -231,6 +716,6 @@ func (h *AuthHandler) GetProfile(c fiber.Ctx) error {
 		Username:   profile.Username,
 		Email:      email,
 		Phone:      profile.Phone,
-		ProfileUrl: profile.ProfileUrl,
+		ProfileUrl: h.profileURL(c, profile.ProfileUrl),
 	})
 }
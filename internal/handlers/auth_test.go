@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/l-fraga2811/back-sable/internal/config"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// newLogoutTestApp serves Logout for a fixed session; Supabase's logout
+// endpoint answers with status and body.
+func newLogoutTestApp(t *testing.T, status int, body string) (*fiber.App, repository.TokenRevocationStore) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/v1/logout" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	revocations := repository.NewMemoryRevocationStore()
+	client := supabase.NewClient(&config.Config{SupabaseURL: srv.URL, SupabaseKey: "anon-key"})
+	handler := NewAuthHandler(client).WithRevocationStore(revocations)
+
+	now := time.Now()
+	claims := supabase.AccessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   testAvatarUserID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		SessionID: "session-1",
+	}
+
+	app := fiber.New()
+	app.Post("/logout", func(c fiber.Ctx) error {
+		c.Locals("claims", claims)
+		c.Locals("token", "user-token")
+		return c.Next()
+	}, handler.Logout)
+	return app, revocations
+}
+
+func TestLogoutRevokesWhenSessionAlreadyEnded(t *testing.T) {
+	app, revocations := newLogoutTestApp(t, http.StatusForbidden,
+		`{"code":403,"error_code":"session_not_found","msg":"Session from session_id claim in JWT does not exist"}`)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/logout", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if revoked, _ := revocations.IsSessionRevoked("session-1"); !revoked {
+		t.Fatal("session was not revoked locally")
+	}
+}
+
+func TestLogoutReportsUpstreamFailure(t *testing.T) {
+	app, revocations := newLogoutTestApp(t, http.StatusInternalServerError, `{"msg":"pq: connection refused"}`)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/logout", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", resp.StatusCode)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "Authentication service unavailable" {
+		t.Fatalf("error = %q, want the generic message", body["error"])
+	}
+	if revoked, _ := revocations.IsSessionRevoked("session-1"); revoked {
+		t.Fatal("session was revoked although Supabase sign-out failed")
+	}
+}
+
+func TestGlobalRevocationCoversTokensFromTheSameSecond(t *testing.T) {
+	revocations := repository.NewMemoryRevocationStore()
+	claims := supabase.AccessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: testAvatarUserID},
+		SessionID:        "session-1",
+	}
+	if err := revokeClaims(revocations, claims, supabase.SignOutScopeGlobal); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another session's token minted right after the logout, in the same
+	// second, carries an iat truncated to that second.
+	issuedAt := jwt.NewNumericDate(time.Now())
+	cutoff, ok, err := revocations.UserRevokedBefore(testAvatarUserID)
+	if err != nil || !ok {
+		t.Fatalf("no cutoff recorded (err: %v)", err)
+	}
+	if !issuedAt.Before(cutoff) {
+		t.Fatalf("token issued at %s is not before cutoff %s", issuedAt.Time, cutoff)
+	}
+}
+
+func TestLoginMapsSupabaseErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"invalid credentials", http.StatusBadRequest, `{"code":400,"error_code":"invalid_credentials","msg":"Invalid login credentials"}`,
+			fiber.StatusUnauthorized, "Invalid email or password"},
+		{"upstream failure", http.StatusInternalServerError, `{"msg":"database error querying schema"}`,
+			fiber.StatusBadGateway, "Authentication service unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			client := supabase.NewClient(&config.Config{SupabaseURL: srv.URL, SupabaseKey: "anon-key"})
+			app := fiber.New()
+			app.Post("/login", NewAuthHandler(client).Login)
+
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"a@example.com","password":"secret"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus || body["error"] != tt.wantError {
+				t.Fatalf("got %d %q, want %d %q", resp.StatusCode, body["error"], tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
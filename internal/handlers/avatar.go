@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	// maxAvatarBytes stays below Fiber's default 4 MB body limit.
+	maxAvatarBytes = 2 << 20
+	// maxAvatarPixels guards against decompression bombs: small files that
+	// decode to huge images.
+	maxAvatarPixels   = 25_000_000
+	avatarJPEGQuality = 85
+)
+
+// avatarSizes are the square variants generated for every upload, largest
+// first. The largest one becomes the profile's profileUrl.
+var avatarSizes = []int{256, 64}
+
+// avatarExtensions are the file extensions variants are stored under, by
+// content type. An upload removes the variants of the other type.
+var avatarExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+}
+
+var errAvatarTooLarge = errors.New("image dimensions are too large")
+
+// WithAvatarStorage enables avatar uploads into bucket. Avatars in a private
+// bucket are stored as their object path and linked with signed URLs, valid
+// for signedURLTTL, created whenever the profile is read.
+func (h *AuthHandler) WithAvatarStorage(bucket string, public bool, signedURLTTL time.Duration) *AuthHandler {
+	h.avatarBucket = bucket
+	h.avatarPublic = public
+	h.avatarURLTTL = signedURLTTL
+	return h
+}
+
+// UploadAvatar accepts a JPEG or PNG in the multipart field "avatar". The
+// image is decoded and re-encoded, which drops EXIF and any other metadata,
+// then center-cropped and scaled into each of avatarSizes and uploaded to
+// Supabase Storage under the caller's user ID.
+func (h *AuthHandler) UploadAvatar(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+	token := requireToken(c)
+	if token == "" {
+		return nil
+	}
+	if h.profileRepo == nil || h.avatarBucket == "" {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
+			"error": "Avatar uploads are not configured",
+		})
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "multipart upload must contain an \"avatar\" field",
+		})
+	}
+	if fileHeader.Size > maxAvatarBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "Avatar must be at most " + strconv.Itoa(maxAvatarBytes>>20) + " MB",
+		})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Could not read upload",
+		})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxAvatarBytes+1))
+	if err != nil || len(data) > maxAvatarBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "Avatar must be at most " + strconv.Itoa(maxAvatarBytes>>20) + " MB",
+		})
+	}
+
+	// Trust the bytes, not the client's declared type.
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Avatar must be a JPEG or PNG image",
+		})
+	}
+
+	img, err := decodeAvatar(data)
+	if err != nil {
+		status := fiber.StatusUnprocessableEntity
+		if errors.Is(err, errAvatarTooLarge) {
+			status = fiber.StatusRequestEntityTooLarge
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": "Invalid image: " + err.Error(),
+		})
+	}
+
+	profile := h.loadOrNewProfile(c, userID)
+	if profile == nil {
+		return nil
+	}
+
+	ext := avatarExtensions[contentType]
+	version := strconv.FormatInt(time.Now().Unix(), 10)
+
+	variants := make(map[string]string, len(avatarSizes))
+	storedURL := ""
+	for _, size := range avatarSizes {
+		encoded, err := encodeAvatar(resizeSquare(img, size), contentType)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Error processing image",
+			})
+		}
+
+		path := avatarPath(userID, size, ext)
+		if err := h.client.UploadObject(c.Context(), token, h.avatarBucket, path, contentType, encoded, true); err != nil {
+			return respondSupabaseError(c, err)
+		}
+
+		objectURL, err := h.avatarURL(c, token, path, version)
+		if err != nil {
+			return respondSupabaseError(c, err)
+		}
+		variants[strconv.Itoa(size)] = objectURL
+		if storedURL == "" {
+			// Signed URLs expire, so private avatars keep only the path.
+			storedURL = path
+			if h.avatarPublic {
+				storedURL = objectURL
+			}
+		}
+	}
+
+	profile.ProfileUrl = storedURL
+	if err := h.profileRepo.Save(profile); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to save profile",
+		})
+	}
+	h.deleteStaleAvatars(c, token, userID, ext)
+	mirrored := h.mirrorProfile(c, token, profile) == nil
+
+	return c.JSON(fiber.Map{
+		"message":  "Avatar atualizado com sucesso",
+		"user":     h.profileUserResponse(c, profile),
+		"variants": variants,
+		"mirrored": mirrored,
+	})
+}
+
+func avatarPath(userID string, size int, ext string) string {
+	return fmt.Sprintf("%s/avatar-%d.%s", userID, size, ext)
+}
+
+// deleteStaleAvatars removes the variants left over from an earlier upload
+// in another format, which the new upload did not overwrite. Failures are
+// only logged: the new avatar is already in place.
+func (h *AuthHandler) deleteStaleAvatars(c fiber.Ctx, token string, userID string, keepExt string) {
+	for _, ext := range avatarExtensions {
+		if ext == keepExt {
+			continue
+		}
+		for _, size := range avatarSizes {
+			path := avatarPath(userID, size, ext)
+			if err := h.client.DeleteObject(c.Context(), token, h.avatarBucket, path); err != nil {
+				log.Printf("Failed to delete stale avatar %s: %v", path, err)
+			}
+		}
+	}
+}
+
+// avatarURL links to an uploaded variant. Public URLs carry the upload
+// version so caches pick up the replaced object.
+func (h *AuthHandler) avatarURL(c fiber.Ctx, token string, path string, version string) (string, error) {
+	if h.avatarPublic {
+		return h.client.PublicObjectURL(h.avatarBucket, path) + "?v=" + version, nil
+	}
+	return h.client.CreateSignedObjectURL(c.Context(), token, h.avatarBucket, path, int(h.avatarURLTTL.Seconds()), "")
+}
+
+// profileURL turns a stored profileUrl into a link for the caller. Uploaded
+// avatars in a private bucket are stored as an object path, which validated
+// profile URLs never are, and are signed afresh on every read.
+func (h *AuthHandler) profileURL(c fiber.Ctx, stored string) string {
+	if !isAvatarObjectPath(stored) || h.avatarBucket == "" {
+		return stored
+	}
+	if h.avatarPublic {
+		return h.client.PublicObjectURL(h.avatarBucket, stored)
+	}
+
+	token, _ := c.Locals("token").(string)
+	signed, err := h.client.CreateSignedObjectURL(c.Context(), token, h.avatarBucket, stored, int(h.avatarURLTTL.Seconds()), "")
+	if err != nil {
+		log.Printf("Failed to sign avatar %s: %v", stored, err)
+		return ""
+	}
+	return signed
+}
+
+func isAvatarObjectPath(stored string) bool {
+	return stored != "" && !strings.Contains(stored, "://")
+}
+
+func decodeAvatar(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, errAvatarTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+func encodeAvatar(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: avatarJPEGQuality})
+	}
+	return buf.Bytes(), err
+}
+
+// resizeSquare center-crops src to a square and scales it down to size
+// pixels by averaging each source box. Images smaller than size are not
+// enlarged.
+func resizeSquare(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	size = min(size, side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		sy0 := y0 + dy*side/size
+		sy1 := max(y0+(dy+1)*side/size, sy0+1)
+		for dx := 0; dx < size; dx++ {
+			sx0 := x0 + dx*side/size
+			sx1 := max(x0+(dx+1)*side/size, sx0+1)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
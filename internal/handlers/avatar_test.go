@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/l-fraga2811/back-sable/internal/config"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+const testAvatarUserID = "0d7c2f4e-5a3b-4c1d-9e8f-7a6b5c4d3e2f"
+
+type memoryProfileRepo struct {
+	mu       sync.Mutex
+	profiles map[string]models.Profile
+}
+
+func (r *memoryProfileRepo) GetByID(id string) (*models.Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	profile, ok := r.profiles[id]
+	if !ok {
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+func (r *memoryProfileRepo) UsernameTaken(username string, excludeID string) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryProfileRepo) Save(profile *models.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles[profile.ID.String()] = *profile
+	return nil
+}
+
+func (r *memoryProfileRepo) Provision(profile *models.Profile) (bool, error) {
+	return false, nil
+}
+
+type storedObject struct {
+	contentType string
+	upsert      string
+	data        []byte
+}
+
+// storageStub stands in for the Supabase Storage and Auth APIs.
+type storageStub struct {
+	mu      sync.Mutex
+	objects map[string]storedObject
+	deleted []string
+}
+
+func (s *storageStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/storage/v1/object/sign/"):
+		object := strings.TrimPrefix(r.URL.Path, "/storage/v1/object/sign/")
+		_ = json.NewEncoder(w).Encode(map[string]string{"signedURL": "/object/sign/" + object + "?token=signed"})
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/storage/v1/object/"):
+		data, _ := io.ReadAll(r.Body)
+		s.objects[strings.TrimPrefix(r.URL.Path, "/storage/v1/object/")] = storedObject{
+			contentType: r.Header.Get("Content-Type"),
+			upsert:      r.Header.Get("x-upsert"),
+			data:        data,
+		}
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/storage/v1/object/"):
+		s.deleted = append(s.deleted, strings.TrimPrefix(r.URL.Path, "/storage/v1/object/"))
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodPut && r.URL.Path == "/auth/v1/user":
+		_, _ = w.Write([]byte(`{"id":"` + testAvatarUserID + `"}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newAvatarTestApp(t *testing.T) (*fiber.App, *storageStub, *memoryProfileRepo) {
+	t.Helper()
+
+	stub := &storageStub{objects: map[string]storedObject{}}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	profiles := &memoryProfileRepo{profiles: map[string]models.Profile{}}
+	client := supabase.NewClient(&config.Config{SupabaseURL: srv.URL, SupabaseKey: "anon-key"})
+	handler := NewAuthHandlerWithProfileRepo(client, profiles).
+		WithAvatarStorage("avatars", false, time.Hour)
+
+	app := fiber.New()
+	app.Post("/avatar", func(c fiber.Ctx) error {
+		c.Locals("userID", testAvatarUserID)
+		c.Locals("token", "user-token")
+		return c.Next()
+	}, handler.UploadAvatar)
+	app.Get("/profile", func(c fiber.Ctx) error {
+		c.Locals("userID", testAvatarUserID)
+		c.Locals("token", "user-token")
+		return c.Next()
+	}, handler.GetProfile)
+	return app, stub, profiles
+}
+
+func uploadAvatar(t *testing.T, app *fiber.App, data []byte) *http.Response {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("avatar", "avatar.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(data)
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := app.Test(req, fiber.TestConfig{Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// jpegWithEXIF encodes a w x h JPEG and inserts an APP1 Exif segment holding
+// a marker string right after the SOI marker.
+func jpegWithEXIF(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := append([]byte("Exif\x00\x00"), []byte("GPS-SECRET-LOCATION")...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	encoded := buf.Bytes()
+	out := append([]byte{}, encoded[:2]...)
+	out = append(out, segment...)
+	return append(out, encoded[2:]...)
+}
+
+// pngHeaderOnly is a PNG whose IHDR claims w x h pixels but has no image
+// data: enough for DecodeConfig, and tiny on the wire.
+func pngHeaderOnly(w, h uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], w)
+	binary.BigEndian.PutUint32(ihdr[4:], h)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 2 // truecolor
+
+	chunk := []byte{0, 0, 0, 13}
+	chunk = append(chunk, "IHDR"...)
+	chunk = append(chunk, ihdr...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk[4:]))
+	chunk = append(chunk, crc...)
+
+	return append([]byte("\x89PNG\r\n\x1a\n"), chunk...)
+}
+
+func TestUploadAvatarRejectsUnsupportedType(t *testing.T) {
+	app, stub, _ := newAvatarTestApp(t)
+
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	resp := uploadAvatar(t, app, gif)
+	if resp.StatusCode != fiber.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415", resp.StatusCode)
+	}
+	if len(stub.objects) != 0 {
+		t.Fatalf("uploaded %d objects, want none", len(stub.objects))
+	}
+}
+
+func TestUploadAvatarRejectsOversizeFile(t *testing.T) {
+	app, _, _ := newAvatarTestApp(t)
+
+	data := make([]byte, maxAvatarBytes+1)
+	copy(data, []byte{0xFF, 0xD8, 0xFF})
+	resp := uploadAvatar(t, app, data)
+	if resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", resp.StatusCode)
+	}
+}
+
+func TestUploadAvatarRejectsPixelBomb(t *testing.T) {
+	app, stub, _ := newAvatarTestApp(t)
+
+	resp := uploadAvatar(t, app, pngHeaderOnly(50_000, 50_000))
+	if resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", resp.StatusCode)
+	}
+	if len(stub.objects) != 0 {
+		t.Fatalf("uploaded %d objects, want none", len(stub.objects))
+	}
+}
+
+func TestUploadAvatarStoresStrippedVariants(t *testing.T) {
+	app, stub, profiles := newAvatarTestApp(t)
+
+	source := jpegWithEXIF(t, 300, 400)
+	if !bytes.Contains(source, []byte("GPS-SECRET-LOCATION")) {
+		t.Fatal("test image has no EXIF marker")
+	}
+
+	resp := uploadAvatar(t, app, source)
+	if resp.StatusCode != fiber.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+
+	for _, size := range avatarSizes {
+		key := "avatars/" + avatarPath(testAvatarUserID, size, "jpg")
+		object, ok := stub.objects[key]
+		if !ok {
+			t.Fatalf("variant %s was not uploaded", key)
+		}
+		if object.contentType != "image/jpeg" || object.upsert != "true" {
+			t.Errorf("%s: content type %q, x-upsert %q", key, object.contentType, object.upsert)
+		}
+		if bytes.Contains(object.data, []byte("Exif")) || bytes.Contains(object.data, []byte("GPS-SECRET-LOCATION")) {
+			t.Errorf("%s still carries EXIF data", key)
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(object.data))
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		if cfg.Width != size || cfg.Height != size {
+			t.Errorf("%s is %dx%d, want %dx%d", key, cfg.Width, cfg.Height, size, size)
+		}
+	}
+
+	// Variants of an earlier PNG upload are removed.
+	for _, size := range avatarSizes {
+		key := "avatars/" + avatarPath(testAvatarUserID, size, "png")
+		found := false
+		for _, deleted := range stub.deleted {
+			found = found || deleted == key
+		}
+		if !found {
+			t.Errorf("stale variant %s was not deleted", key)
+		}
+	}
+
+	// A private bucket keeps the object path and signs it on every read.
+	profile, _ := profiles.GetByID(testAvatarUserID)
+	if want := avatarPath(testAvatarUserID, avatarSizes[0], "jpg"); profile == nil || profile.ProfileUrl != want {
+		t.Fatalf("stored profileUrl = %+v, want %s", profile, want)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var user userResponse
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(user.ProfileUrl, "/storage/v1/object/sign/avatars/") || !strings.HasSuffix(user.ProfileUrl, "?token=signed") {
+		t.Fatalf("profileUrl = %q, want a signed URL", user.ProfileUrl)
+	}
+}
+
+func TestProfileURLLeavesExternalLinks(t *testing.T) {
+	app, _, profiles := newAvatarTestApp(t)
+
+	_ = profiles.Save(&models.Profile{ID: uuid.MustParse(testAvatarUserID), ProfileUrl: "https://example.com/me.png"})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/profile", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var user userResponse
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.ProfileUrl != "https://example.com/me.png" {
+		t.Fatalf("profileUrl = %q", user.ProfileUrl)
+	}
+}
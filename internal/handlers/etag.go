@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+)
+
+// itemETag derives a strong entity tag from the item's version column.
+func itemETag(item *models.Item) string {
+	return `"` + strconv.Itoa(item.Version) + `"`
+}
+
+func setItemETag(c fiber.Ctx, item *models.Item) {
+	c.Set(fiber.HeaderETag, itemETag(item))
+}
+
+// ifMatchSatisfied reports whether the request's If-Match header, if any,
+// matches the current representation of item. A missing header or "*" always
+// matches so that clients without ETag support keep working.
+func ifMatchSatisfied(c fiber.Ctx, item *models.Item) bool {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := itemETag(item)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		candidate = strings.TrimPrefix(candidate, "W/")
+		if candidate == current {
+			return true
+		}
+	}
+	return false
+}
+
+func preconditionFailed(c fiber.Ctx) error {
+	return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"error": "Item has been modified by another request"})
+}
@@ -1,21 +1,56 @@
 package handlers
 
 import (
+    "errors"
+    "strconv"
+    "time"
+
     "github.com/gofiber/fiber/v3"
     "github.com/l-fraga2811/back-sable/internal/models"
     "github.com/l-fraga2811/back-sable/internal/repository"
 )
 
 type ItemHandler struct {
-    itemRepo repository.ItemRepository
+    itemRepo    repository.ItemRepository
+    revisions   repository.ItemRevisionRepository
+    shares      repository.ItemShareRepository
+    attachments repository.ItemAttachmentRepository
+    blobs       repository.BlobStore
+    profiles    repository.ProfileRepository
 }
 
-func NewItemHandler(itemRepo repository.ItemRepository) *ItemHandler {
+func NewItemHandler(itemRepo repository.ItemRepository, revisions repository.ItemRevisionRepository, shares repository.ItemShareRepository) *ItemHandler {
     return &ItemHandler{
-        itemRepo: itemRepo,
+        itemRepo:  itemRepo,
+        revisions: revisions,
+        shares:    shares,
     }
 }
 
+// itemTx holds the repositories bound to one item transaction, so an item
+// write, its revision and the permission checks around it commit or roll
+// back together.
+type itemTx struct {
+    repository.ItemRepository
+    revisions repository.ItemRevisionRepository
+    shares    repository.ItemShareRepository
+}
+
+func (h *ItemHandler) bindTx(tx repository.ItemRepository) itemTx {
+    return itemTx{
+        ItemRepository: tx,
+        revisions:      h.revisions.WithTx(tx),
+        shares:         h.shares.WithTx(tx),
+    }
+}
+
+// transaction runs fn inside an ItemRepository transaction.
+func (h *ItemHandler) transaction(fn func(tx itemTx) error) error {
+    return h.transaction(func(tx itemTx) error {
+        return fn(h.bindTx(tx))
+    })
+}
+
 func (h *ItemHandler) Create(c fiber.Ctx) error {
     userID := h.requireAuth(c)
     if userID == "" {
@@ -26,6 +61,9 @@ func (h *ItemHandler) Create(c fiber.Ctx) error {
     if err := c.Bind().Body(&req); err != nil {
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
     }
+    if err := req.Validate(); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+    }
 
     item := &models.Item{
         UserID:      userID,
@@ -35,10 +73,17 @@ func (h *ItemHandler) Create(c fiber.Ctx) error {
         Completed:   false,
     }
 
-    if err := h.itemRepo.Create(item); err != nil {
+    err := h.transaction(func(tx itemTx) error {
+        if err := tx.Create(item); err != nil {
+            return err
+        }
+        return recordRevision(tx, models.RevisionActionCreate, userID, nil, item)
+    })
+    if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error creating item"})
     }
 
+    setItemETag(c, item)
     return c.Status(fiber.StatusCreated).JSON(item)
 }
 
@@ -48,12 +93,63 @@ func (h *ItemHandler) GetAll(c fiber.Ctx) error {
         return nil
     }
 
-    items, err := h.itemRepo.GetAll(userID)
+    opts, err := parseItemListOptions(c)
     if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    }
+
+    page, err := h.itemRepo.List(userID, opts)
+    if err != nil {
+        if errors.Is(err, repository.ErrInvalidCursor) || errors.Is(err, repository.ErrInvalidSortField) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        }
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching items"})
     }
 
-    return c.JSON(items)
+    items := page.Items
+    if items == nil {
+        items = []models.Item{}
+    }
+
+    return c.JSON(fiber.Map{
+        "data":        items,
+        "next_cursor": page.NextCursor,
+        "limit":       opts.Limit,
+    })
+}
+
+func (h *ItemHandler) Search(c fiber.Ctx) error {
+    userID := h.requireAuth(c)
+    if userID == "" {
+        return nil
+    }
+
+    query := c.Query("q")
+    if query == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q is required"})
+    }
+
+    limit := repository.DefaultItemPageSize
+    if raw := c.Query("limit"); raw != "" {
+        parsed, err := strconv.Atoi(raw)
+        if err != nil || parsed < 1 || parsed > repository.MaxItemPageSize {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must be between 1 and " + strconv.Itoa(repository.MaxItemPageSize)})
+        }
+        limit = parsed
+    }
+
+    results, err := h.itemRepo.Search(userID, query, limit)
+    if err != nil {
+        if errors.Is(err, repository.ErrEmptySearch) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error searching items"})
+    }
+    if results == nil {
+        results = []repository.ItemSearchResult{}
+    }
+
+    return c.JSON(fiber.Map{"data": results})
 }
 
 func (h *ItemHandler) GetByID(c fiber.Ctx) error {
@@ -68,10 +164,12 @@ func (h *ItemHandler) GetByID(c fiber.Ctx) error {
         return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
     }
 
-    if item.UserID != userID {
-        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You do not have permission to access this item"})
+    if !authorizeItem(c, h.shares, item, userID, accessViewer, "access") {
+        return nil
     }
 
+    redactItemFor(item, userID)
+    setItemETag(c, item)
     return c.JSON(item)
 }
 
@@ -87,8 +185,12 @@ func (h *ItemHandler) Update(c fiber.Ctx) error {
         return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
     }
 
-    if item.UserID != userID {
-        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You do not have permission to update this item"})
+    if !authorizeItem(c, h.shares, item, userID, accessEditor, "update") {
+        return nil
+    }
+
+    if !ifMatchSatisfied(c, item) {
+        return preconditionFailed(c)
     }
 
     var req models.UpdateItemRequest
@@ -96,21 +198,77 @@ func (h *ItemHandler) Update(c fiber.Ctx) error {
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
     }
 
-    if req.Title != "" {
-        item.Title = req.Title
+    if err := req.Validate(); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
     }
-    if req.Description != "" {
-        item.Description = req.Description
+
+    before := *item
+    req.ApplyTo(item)
+
+    err = h.transaction(func(tx itemTx) error {
+        if err := tx.Update(item); err != nil {
+            return err
+        }
+        return recordRevision(tx, models.RevisionActionUpdate, userID, &before, item)
+    })
+    if err != nil {
+        if errors.Is(err, repository.ErrVersionConflict) {
+            return preconditionFailed(c)
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error updating item"})
     }
-    if req.Price != 0 {
-        item.Price = req.Price
+
+    redactItemFor(item, userID)
+    setItemETag(c, item)
+    return c.JSON(item)
+}
+
+func (h *ItemHandler) Patch(c fiber.Ctx) error {
+    userID := h.requireAuth(c)
+    if userID == "" {
+        return nil
     }
-    item.Completed = req.Completed
 
-    if err := h.itemRepo.Update(item); err != nil {
+    itemID := c.Params("id")
+    item, err := h.itemRepo.GetByID(itemID)
+    if err != nil {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+    }
+
+    if !authorizeItem(c, h.shares, item, userID, accessEditor, "update") {
+        return nil
+    }
+
+    if !ifMatchSatisfied(c, item) {
+        return preconditionFailed(c)
+    }
+
+    var req models.PatchItemRequest
+    if err := c.Bind().Body(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+    }
+    if err := req.Validate(); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+    }
+
+    before := *item
+    req.ApplyTo(item)
+
+    err = h.transaction(func(tx itemTx) error {
+        if err := tx.Update(item); err != nil {
+            return err
+        }
+        return recordRevision(tx, models.RevisionActionUpdate, userID, &before, item)
+    })
+    if err != nil {
+        if errors.Is(err, repository.ErrVersionConflict) {
+            return preconditionFailed(c)
+        }
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error updating item"})
     }
 
+    redactItemFor(item, userID)
+    setItemETag(c, item)
     return c.JSON(item)
 }
 
@@ -120,17 +278,34 @@ func (h *ItemHandler) Delete(c fiber.Ctx) error {
         return nil
     }
 
+    if c.Query("permanent") == "true" {
+        return h.purge(c, userID)
+    }
+
     itemID := c.Params("id")
     item, err := h.itemRepo.GetByID(itemID)
     if err != nil {
         return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
     }
 
-    if item.UserID != userID {
-        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You do not have permission to delete this item"})
+    if !authorizeItem(c, h.shares, item, userID, accessOwner, "delete") {
+        return nil
+    }
+
+    if !ifMatchSatisfied(c, item) {
+        return preconditionFailed(c)
     }
 
-    if err := h.itemRepo.Delete(itemID); err != nil {
+    err = h.transaction(func(tx itemTx) error {
+        if err := tx.Delete(item); err != nil {
+            return err
+        }
+        return recordRevision(tx, models.RevisionActionDelete, userID, item, item)
+    })
+    if err != nil {
+        if errors.Is(err, repository.ErrVersionConflict) {
+            return preconditionFailed(c)
+        }
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error deleting item"})
     }
 
@@ -138,10 +313,106 @@ func (h *ItemHandler) Delete(c fiber.Ctx) error {
 }
 
 func (h *ItemHandler) requireAuth(c fiber.Ctx) string {
+    return requireUserID(c)
+}
+
+// requireUserID returns the authenticated user's ID, or writes a 401 response
+// and returns "" when there is none.
+func requireUserID(c fiber.Ctx) string {
     userID, ok := c.Locals("userID").(string)
     if !ok || userID == "" {
         c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User not authenticated"})
         return ""
     }
     return userID
-}
\ No newline at end of file
+}
+
+// parseItemListOptions reads pagination, filter and sort parameters from the
+// query string:
+//
+//	limit, cursor, completed, min_price, max_price,
+//	created_after, created_before (RFC 3339), sort, order (asc|desc),
+//	tag (repeatable), tag_match (any|all)
+func parseItemListOptions(c fiber.Ctx) (repository.ItemListOptions, error) {
+    opts := repository.ItemListOptions{
+        Limit:     repository.DefaultItemPageSize,
+        Cursor:    c.Query("cursor"),
+        SortField: c.Query("sort", "created_at"),
+    }
+
+    for _, tag := range c.Request().URI().QueryArgs().PeekMulti("tag") {
+        opts.Tags = append(opts.Tags, string(tag))
+    }
+    switch c.Query("tag_match", "any") {
+    case "any":
+        opts.MatchAllTags = false
+    case "all":
+        opts.MatchAllTags = true
+    default:
+        return opts, errors.New("tag_match must be any or all")
+    }
+
+    if raw := c.Query("limit"); raw != "" {
+        limit, err := strconv.Atoi(raw)
+        if err != nil || limit < 1 || limit > repository.MaxItemPageSize {
+            return opts, errors.New("limit must be between 1 and " + strconv.Itoa(repository.MaxItemPageSize))
+        }
+        opts.Limit = limit
+    }
+
+    if !repository.IsValidItemSortField(opts.SortField) {
+        return opts, errors.New("sort must be one of created_at, updated_at, title, price")
+    }
+
+    switch c.Query("order", "desc") {
+    case "asc":
+        opts.SortAsc = true
+    case "desc":
+        opts.SortAsc = false
+    default:
+        return opts, errors.New("order must be asc or desc")
+    }
+
+    if raw := c.Query("completed"); raw != "" {
+        completed, err := strconv.ParseBool(raw)
+        if err != nil {
+            return opts, errors.New("completed must be true or false")
+        }
+        opts.Completed = &completed
+    }
+
+    if raw := c.Query("min_price"); raw != "" {
+        price, err := strconv.ParseFloat(raw, 64)
+        if err != nil {
+            return opts, errors.New("min_price must be a number")
+        }
+        opts.MinPrice = &price
+    }
+    if raw := c.Query("max_price"); raw != "" {
+        price, err := strconv.ParseFloat(raw, 64)
+        if err != nil {
+            return opts, errors.New("max_price must be a number")
+        }
+        opts.MaxPrice = &price
+    }
+    if opts.MinPrice != nil && opts.MaxPrice != nil && *opts.MinPrice > *opts.MaxPrice {
+        return opts, errors.New("min_price must not be greater than max_price")
+    }
+
+    if raw := c.Query("created_after"); raw != "" {
+        ts, err := time.Parse(time.RFC3339, raw)
+        if err != nil {
+            return opts, errors.New("created_after must be an RFC 3339 timestamp")
+        }
+        opts.CreatedAfter = &ts
+    }
+    if raw := c.Query("created_before"); raw != "" {
+        ts, err := time.Parse(time.RFC3339, raw)
+        if err != nil {
+            return opts, errors.New("created_before must be an RFC 3339 timestamp")
+        }
+        opts.CreatedBefore = &ts
+    }
+
+    return opts, nil
+}
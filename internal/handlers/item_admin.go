@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+// AdminList pages through every user's items, or a single user's with
+// ?user_id=. It accepts the same filters as GetAll.
+func (h *ItemHandler) AdminList(c fiber.Ctx) error {
+	if h.requireAuth(c) == "" {
+		return nil
+	}
+
+	opts, err := parseItemListOptions(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var page repository.ItemPage
+	if owner := c.Query("user_id"); owner != "" {
+		page, err = h.itemRepo.List(owner, opts)
+	} else {
+		page, err = h.itemRepo.ListAll(opts)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) || errors.Is(err, repository.ErrInvalidSortField) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching items"})
+	}
+
+	items := page.Items
+	if items == nil {
+		items = []models.Item{}
+	}
+
+	return c.JSON(fiber.Map{
+		"data":        items,
+		"next_cursor": page.NextCursor,
+		"limit":       opts.Limit,
+	})
+}
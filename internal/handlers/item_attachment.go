@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+const (
+	// maxAttachmentBytes leaves room for multipart overhead below Fiber's
+	// default 4 MB body limit. That limit is server-wide and Fiber buffers a
+	// whole body before any handler runs, so raising it for larger files
+	// would let every route, unauthenticated ones included, be sent bodies
+	// that size.
+	maxAttachmentBytes = 3 << 20
+	// attachmentURLTTL is how long download links stay valid.
+	attachmentURLTTL = 5 * time.Minute
+)
+
+// attachmentContentTypes are the sniffed content types accepted as
+// attachments: receipts and photos.
+var attachmentContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// WithAttachments enables attachments: rows in attachments, files in blobs.
+func (h *ItemHandler) WithAttachments(attachments repository.ItemAttachmentRepository, blobs repository.BlobStore) *ItemHandler {
+	h.attachments = attachments
+	h.blobs = blobs
+	return h
+}
+
+// loadAttachableItem fetches the :id item and checks the caller has need on
+// it, exactly like the item endpoints do.
+func (h *ItemHandler) loadAttachableItem(c fiber.Ctx, userID string, need itemAccess, action string) *models.Item {
+	if h.blobs == nil {
+		_ = c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{"error": "Attachments are not configured"})
+		return nil
+	}
+
+	item, err := h.itemRepo.GetByID(c.Params("id"))
+	if err != nil {
+		_ = c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+		return nil
+	}
+	if !authorizeItem(c, h.shares, item, userID, need, action) {
+		return nil
+	}
+	return item
+}
+
+func (h *ItemHandler) UploadAttachment(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+	item := h.loadAttachableItem(c, userID, accessEditor, "update")
+	if item == nil {
+		return nil
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "multipart upload must contain a \"file\" field"})
+	}
+	tooLarge := "Attachment must be at most " + strconv.Itoa(maxAttachmentBytes>>20) + " MB"
+	if fileHeader.Size > maxAttachmentBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": tooLarge})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Could not read upload"})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentBytes+1))
+	if err != nil || len(data) > maxAttachmentBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": tooLarge})
+	}
+
+	contentType := http.DetectContentType(data)
+	if !attachmentContentTypes[contentType] {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{"error": "Attachments must be JPEG, PNG, GIF, WebP or PDF files"})
+	}
+
+	att := &models.ItemAttachment{
+		ID:          uuid.NewString(),
+		ItemID:      item.ID,
+		UploadedBy:  userID,
+		Filename:    attachmentFilename(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	att.StorageKey = "items/" + item.ID + "/" + att.ID
+
+	if err := h.blobs.Put(c.Context(), att.StorageKey, contentType, data); err != nil {
+		log.Printf("Failed to store attachment %s: %v", att.StorageKey, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Error storing attachment"})
+	}
+	if err := h.attachments.Create(att); err != nil {
+		if err := h.blobs.Delete(c.Context(), att.StorageKey); err != nil {
+			log.Printf("Failed to clean up attachment %s: %v", att.StorageKey, err)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error saving attachment"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(att)
+}
+
+func (h *ItemHandler) ListAttachments(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+	item := h.loadAttachableItem(c, userID, accessViewer, "access")
+	if item == nil {
+		return nil
+	}
+
+	atts, err := h.attachments.ListByItem(item.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching attachments"})
+	}
+	if atts == nil {
+		atts = []models.ItemAttachment{}
+	}
+
+	return c.JSON(atts)
+}
+
+// DownloadAttachment answers with a short-lived signed URL instead of the file
+// itself, so downloads do not go through the API.
+func (h *ItemHandler) DownloadAttachment(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+	item := h.loadAttachableItem(c, userID, accessViewer, "access")
+	if item == nil {
+		return nil
+	}
+
+	att, err := h.attachments.Get(item.ID, c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	}
+
+	expiresAt := time.Now().Add(attachmentURLTTL)
+	url, err := h.blobs.SignedURL(c.Context(), att.StorageKey, att.Filename, attachmentURLTTL)
+	if err != nil {
+		log.Printf("Failed to sign attachment %s: %v", att.StorageKey, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Error creating download link"})
+	}
+
+	return c.JSON(fiber.Map{
+		"attachment": att,
+		"url":        url,
+		"expires_at": expiresAt.UTC(),
+	})
+}
+
+func (h *ItemHandler) DeleteAttachment(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+	item := h.loadAttachableItem(c, userID, accessEditor, "update")
+	if item == nil {
+		return nil
+	}
+
+	att, err := h.attachments.Get(item.ID, c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	}
+
+	if err := h.attachments.Delete(att.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error deleting attachment"})
+	}
+	// The row is gone, so a failure here only leaves an unreachable file.
+	if err := h.blobs.Delete(c.Context(), att.StorageKey); err != nil {
+		log.Printf("Failed to delete attachment blob %s: %v", att.StorageKey, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Attachment deleted"})
+}
+
+// ServeLocalBlob serves downloads signed by a LocalBlobStore. It is only
+// mounted in development, when attachments are kept on disk.
+func ServeLocalBlob(store *repository.LocalBlobStore) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		filename := c.Query("name")
+		file, err := store.Open(c.Params("*"), c.Query("expires"), filename, c.Query("sig"))
+		if err != nil {
+			if errors.Is(err, repository.ErrBlobNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "File not found"})
+			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid or expired link"})
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error reading file"})
+		}
+		c.Set(fiber.HeaderContentType, http.DetectContentType(data))
+		c.Set("X-Content-Type-Options", "nosniff")
+		if filename != "" {
+			c.Set(fiber.HeaderContentDisposition, contentDisposition(filename))
+		}
+		return c.Send(data)
+	}
+}
+
+// contentDisposition builds an attachment Content-Disposition header with the
+// filename percent-encoded as RFC 5987 requires, so any UTF-8 name is safe.
+func contentDisposition(filename string) string {
+	const attrChars = "!#$&+-.^_`|~"
+	var b strings.Builder
+	b.WriteString("attachment; filename*=UTF-8''")
+	for i := 0; i < len(filename); i++ {
+		ch := filename[i]
+		if ch < 0x80 && (ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || strings.IndexByte(attrChars, ch) >= 0) {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// attachmentFilename keeps only the base name of an uploaded file, without
+// control characters, for display and Content-Disposition.
+func attachmentFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+func TestServeLocalBlobSetsContentDisposition(t *testing.T) {
+	store, err := repository.NewLocalBlobStore(t.TempDir(), "http://localhost:3000", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(context.Background(), "items/1/2", "application/pdf", []byte("%PDF-1.4\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get(repository.LocalBlobPath+"*", ServeLocalBlob(store))
+
+	signed, err := store.SignedURL(context.Background(), "items/1/2", "recibo de março; final.pdf", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	want := "attachment; filename*=UTF-8''recibo%20de%20mar%C3%A7o%3B%20final.pdf"
+	if got := resp.Header.Get(fiber.HeaderContentDisposition); got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+
+	// The name is covered by the signature.
+	q := u.Query()
+	q.Set("name", "other.pdf")
+	u.RawQuery = q.Encode()
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusForbidden {
+		t.Errorf("tampered name: status = %d, want 403", resp.StatusCode)
+	}
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+const maxBatchOperations = 500
+
+const (
+	batchModeAtomic     = "atomic"
+	batchModeBestEffort = "best_effort"
+)
+
+type batchOperation struct {
+	Op      string          `json:"op"`
+	ID      string          `json:"id,omitempty"`
+	Version *int            `json:"version,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type batchRequest struct {
+	Mode       string           `json:"mode"`
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	ID     string       `json:"id,omitempty"`
+	Status int          `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Item   *models.Item `json:"item,omitempty"`
+}
+
+// batchError carries the HTTP status reported for a single failed operation.
+type batchError struct {
+	status  int
+	message string
+}
+
+func (e *batchError) Error() string {
+	return e.message
+}
+
+// Batch applies a list of create, update, delete and complete operations in a
+// single transaction. In atomic mode (the default) the first failure rolls
+// everything back; in best_effort mode each operation runs in its own
+// savepoint and failures are reported without affecting the others.
+func (h *ItemHandler) Batch(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	var req batchRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+	}
+	if req.Mode == "" {
+		req.Mode = batchModeAtomic
+	}
+	if req.Mode != batchModeAtomic && req.Mode != batchModeBestEffort {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "mode must be atomic or best_effort"})
+	}
+	if len(req.Operations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "operations must not be empty"})
+	}
+	if len(req.Operations) > maxBatchOperations {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "a batch may contain at most " + strconv.Itoa(maxBatchOperations) + " operations"})
+	}
+
+	results := make([]batchResult, len(req.Operations))
+	failed := -1
+
+	err := h.transaction(func(tx itemTx) error {
+		for i, op := range req.Operations {
+			if req.Mode == batchModeAtomic {
+				results[i] = h.applyBatchOperation(tx, userID, i, op)
+				if results[i].Error != "" {
+					failed = i
+					return &batchError{results[i].Status, results[i].Error}
+				}
+				continue
+			}
+
+			_ = tx.Transaction(func(sp repository.ItemRepository) error {
+				results[i] = h.applyBatchOperation(h.bindTx(sp), userID, i, op)
+				if results[i].Error != "" {
+					return &batchError{results[i].Status, results[i].Error}
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	if err != nil && failed < 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error applying batch"})
+	}
+
+	if failed >= 0 {
+		for i := range results {
+			if i == failed {
+				continue
+			}
+			results[i] = batchResult{
+				Index:  i,
+				Op:     req.Operations[i].Op,
+				ID:     req.Operations[i].ID,
+				Status: fiber.StatusFailedDependency,
+				Error:  "not applied: operation " + strconv.Itoa(failed) + " failed",
+			}
+		}
+	}
+
+	status := fiber.StatusOK
+	for _, result := range results {
+		if result.Error != "" {
+			status = fiber.StatusMultiStatus
+			break
+		}
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"mode":      req.Mode,
+		"committed": failed < 0,
+		"results":   results,
+	})
+}
+
+func (h *ItemHandler) applyBatchOperation(tx itemTx, userID string, index int, op batchOperation) batchResult {
+	result := batchResult{Index: index, Op: op.Op, ID: op.ID}
+
+	var (
+		item *models.Item
+		berr *batchError
+	)
+	switch op.Op {
+	case "create":
+		item, berr = batchCreate(tx, userID, op)
+	case "update":
+		item, berr = batchUpdate(tx, userID, op)
+	case "complete":
+		item, berr = batchComplete(tx, userID, op)
+	case "delete":
+		berr = batchDelete(tx, userID, op)
+	default:
+		berr = &batchError{fiber.StatusBadRequest, "op must be one of create, update, delete, complete"}
+	}
+
+	if berr != nil {
+		result.Status = berr.status
+		result.Error = berr.message
+		return result
+	}
+
+	result.Status = fiber.StatusOK
+	if op.Op == "create" {
+		result.Status = fiber.StatusCreated
+	}
+	if item != nil {
+		redactItemFor(item, userID)
+		result.ID = item.ID
+		result.Item = item
+	}
+	return result
+}
+
+// loadBatchItem fetches op.ID and applies the same permission and version
+// checks the single-item endpoints perform.
+func loadBatchItem(tx itemTx, userID string, op batchOperation, need itemAccess) (*models.Item, *batchError) {
+	if op.ID == "" {
+		return nil, &batchError{fiber.StatusBadRequest, "id is required"}
+	}
+
+	item, err := tx.GetByID(op.ID)
+	if err != nil {
+		return nil, &batchError{fiber.StatusNotFound, "Item not found"}
+	}
+	access, err := resolveItemAccess(tx.shares, item, userID)
+	if err != nil {
+		return nil, &batchError{fiber.StatusInternalServerError, "Error checking item permissions"}
+	}
+	if access < need {
+		return nil, &batchError{fiber.StatusForbidden, "You do not have permission to modify this item"}
+	}
+	if op.Version != nil && *op.Version != item.Version {
+		return nil, &batchError{fiber.StatusPreconditionFailed, "Item has been modified by another request"}
+	}
+	return item, nil
+}
+
+func invalidBatchData(err error) *batchError {
+	return &batchError{fiber.StatusBadRequest, "Invalid data: " + err.Error()}
+}
+
+func batchCreate(tx itemTx, userID string, op batchOperation) (*models.Item, *batchError) {
+	var req models.CreateItemRequest
+	if err := json.Unmarshal(op.Data, &req); err != nil {
+		return nil, invalidBatchData(err)
+	}
+	if err := req.Validate(); err != nil {
+		return nil, invalidBatchData(err)
+	}
+
+	item := &models.Item{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+		Price:       req.Price,
+		Completed:   false,
+	}
+	if err := tx.Create(item); err != nil {
+		return nil, &batchError{fiber.StatusInternalServerError, "Error creating item"}
+	}
+	if err := recordRevision(tx, models.RevisionActionCreate, userID, nil, item); err != nil {
+		return nil, &batchError{fiber.StatusInternalServerError, "Error creating item"}
+	}
+	return item, nil
+}
+
+func batchUpdate(tx itemTx, userID string, op batchOperation) (*models.Item, *batchError) {
+	item, berr := loadBatchItem(tx, userID, op, accessEditor)
+	if berr != nil {
+		return nil, berr
+	}
+
+	var req models.PatchItemRequest
+	if err := json.Unmarshal(op.Data, &req); err != nil {
+		return nil, invalidBatchData(err)
+	}
+	if err := req.Validate(); err != nil {
+		return nil, invalidBatchData(err)
+	}
+
+	before := *item
+	req.ApplyTo(item)
+	return saveBatchItem(tx, userID, &before, item)
+}
+
+func batchComplete(tx itemTx, userID string, op batchOperation) (*models.Item, *batchError) {
+	item, berr := loadBatchItem(tx, userID, op, accessEditor)
+	if berr != nil {
+		return nil, berr
+	}
+
+	before := *item
+	item.Completed = true
+	return saveBatchItem(tx, userID, &before, item)
+}
+
+func batchDelete(tx itemTx, userID string, op batchOperation) *batchError {
+	item, berr := loadBatchItem(tx, userID, op, accessOwner)
+	if berr != nil {
+		return berr
+	}
+
+	if err := tx.Delete(item); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return &batchError{fiber.StatusPreconditionFailed, "Item has been modified by another request"}
+		}
+		return &batchError{fiber.StatusInternalServerError, "Error deleting item"}
+	}
+	if err := recordRevision(tx, models.RevisionActionDelete, userID, item, item); err != nil {
+		return &batchError{fiber.StatusInternalServerError, "Error deleting item"}
+	}
+	return nil
+}
+
+func saveBatchItem(tx itemTx, userID string, before *models.Item, item *models.Item) (*models.Item, *batchError) {
+	if err := tx.Update(item); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, &batchError{fiber.StatusPreconditionFailed, "Item has been modified by another request"}
+		}
+		return nil, &batchError{fiber.StatusInternalServerError, "Error updating item"}
+	}
+	if err := recordRevision(tx, models.RevisionActionUpdate, userID, before, item); err != nil {
+		return nil, &batchError{fiber.StatusInternalServerError, "Error updating item"}
+	}
+	return item, nil
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+const exportBatchSize = repository.MaxItemPageSize
+
+var itemCSVHeader = []string{"id", "title", "description", "price", "completed", "created_at", "updated_at"}
+
+// Export streams all of the caller's items as csv, json or ndjson. Items are
+// read and written one batch at a time, so large lists are never held in
+// memory. Once streaming has started the status can no longer change, so a
+// failure part-way through is only logged and truncates the output.
+func (h *ItemHandler) Export(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	format := c.Query("format", "json")
+	var (
+		contentType string
+		write       func(w *bufio.Writer) error
+	)
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+		write = func(w *bufio.Writer) error { return h.exportCSV(w, userID) }
+	case "json":
+		contentType = fiber.MIMEApplicationJSONCharsetUTF8
+		write = func(w *bufio.Writer) error { return h.exportJSON(w, userID) }
+	case "ndjson":
+		contentType = "application/x-ndjson"
+		write = func(w *bufio.Writer) error { return h.exportNDJSON(w, userID) }
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be csv, json or ndjson"})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="items.`+format+`"`)
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			log.Printf("Item export for user %s failed: %v", userID, err)
+		}
+		_ = w.Flush()
+	})
+}
+
+func (h *ItemHandler) exportCSV(w *bufio.Writer, userID string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(itemCSVHeader); err != nil {
+		return err
+	}
+
+	err := h.itemRepo.ForEachBatch(userID, exportBatchSize, func(items []models.Item) error {
+		for _, item := range items {
+			record := []string{
+				item.ID,
+				csvSafeCell(item.Title),
+				csvSafeCell(item.Description),
+				strconv.FormatFloat(item.Price, 'f', -1, 64),
+				strconv.FormatBool(item.Completed),
+				item.CreatedAt.UTC().Format(time.RFC3339),
+				item.UpdatedAt.UTC().Format(time.RFC3339),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+	cw.Flush()
+	return err
+}
+
+// csvFormulaPrefixes are the leading characters that make spreadsheet
+// applications evaluate a cell as a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvSafeCell prefixes cells that spreadsheet applications would evaluate as
+// a formula with a quote, so user text cannot run as one (CSV injection).
+// Values that already start with quotes before such a character get one more,
+// so csvUnescapeCell on import always restores the original text.
+func csvSafeCell(value string) string {
+	rest := strings.TrimLeft(value, "'")
+	if rest != "" && strings.ContainsRune(csvFormulaPrefixes, rune(rest[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvUnescapeCell undoes csvSafeCell: it drops one leading quote when what
+// follows would have needed one.
+func csvUnescapeCell(value string) string {
+	if rest, ok := strings.CutPrefix(value, "'"); ok && csvSafeCell(rest) != rest {
+		return rest
+	}
+	return value
+}
+
+func (h *ItemHandler) exportJSON(w *bufio.Writer, userID string) error {
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+
+	first := true
+	err := h.itemRepo.ForEachBatch(userID, exportBatchSize, func(items []models.Item) error {
+		for _, item := range items {
+			b, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.WriteString("]")
+	return err
+}
+
+func (h *ItemHandler) exportNDJSON(w *bufio.Writer, userID string) error {
+	return h.itemRepo.ForEachBatch(userID, exportBatchSize, func(items []models.Item) error {
+		for _, item := range items {
+			b, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+			if err := w.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+// exportItemRepo serves a fixed item list to ForEachBatch; the other
+// ItemRepository methods are not used by the export.
+type exportItemRepo struct {
+	repository.ItemRepository
+	items []models.Item
+}
+
+func (r *exportItemRepo) ForEachBatch(userID string, batchSize int, fn func(items []models.Item) error) error {
+	return fn(r.items)
+}
+
+func TestCSVExportImportRoundTrip(t *testing.T) {
+	items := []models.Item{
+		{ID: "1", Title: "Groceries", Description: "plain text", Price: 12.5},
+		{ID: "2", Title: "-5 off", Description: "=SUM(A1:A2)", Completed: true},
+		{ID: "3", Title: "+55 11 99999-0000", Description: "@mention"},
+		{ID: "4", Title: "'=already quoted", Description: "''-twice"},
+		{ID: "5", Title: "'just a quote", Description: "\tindented"},
+	}
+	handler := NewItemHandler(&exportItemRepo{items: items}, nil, nil)
+
+	// Two round trips: the second must not add another layer of quotes.
+	data := exportItemsCSV(t, handler)
+	for trip := 1; trip <= 2; trip++ {
+		rows, rowErrors, err := parseCSVImport(data)
+		if err != nil {
+			t.Fatalf("trip %d: parseCSVImport: %v", trip, err)
+		}
+		if len(rowErrors) != 0 {
+			t.Fatalf("trip %d: row errors %+v", trip, rowErrors)
+		}
+		if len(rows) != len(items) {
+			t.Fatalf("trip %d: got %d rows, want %d", trip, len(rows), len(items))
+		}
+		for i, row := range rows {
+			want := items[i]
+			if row.Title != want.Title || row.Description != want.Description ||
+				row.Price != want.Price || row.Completed != want.Completed {
+				t.Errorf("trip %d, row %d = %+v, want %+v", trip, i, row, want)
+			}
+		}
+
+		reimported := make([]models.Item, len(rows))
+		for i, row := range rows {
+			reimported[i] = models.Item{
+				ID:          items[i].ID,
+				Title:       row.Title,
+				Description: row.Description,
+				Price:       row.Price,
+				Completed:   row.Completed,
+			}
+		}
+		data = exportItemsCSV(t, NewItemHandler(&exportItemRepo{items: reimported}, nil, nil))
+	}
+}
+
+func TestCSVSafeCellNeutralizesFormulas(t *testing.T) {
+	for _, value := range []string{"=1+1", "+1", "-1", "@SUM(A1)", "\tx", "\rx", "'=1+1"} {
+		if got := csvSafeCell(value); got[0] != '\'' {
+			t.Errorf("csvSafeCell(%q) = %q, want a leading quote", value, got)
+		}
+	}
+	for _, value := range []string{"", "plain", "'quoted", "1-1"} {
+		if got := csvSafeCell(value); got != value {
+			t.Errorf("csvSafeCell(%q) = %q, want it unchanged", value, got)
+		}
+	}
+}
+
+func exportItemsCSV(t *testing.T, handler *ItemHandler) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := handler.exportCSV(w, "user"); err != nil {
+		t.Fatalf("exportCSV: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+// recordRevision appends a revision for a write to item. before is the state
+// prior to the write and is nil for creations. It must be called with the
+// same transaction that performed the write.
+func recordRevision(tx itemTx, action string, actorID string, before *models.Item, item *models.Item) error {
+	rev, err := newRevision(action, actorID, before, item)
+	if err != nil {
+		return err
+	}
+	return tx.revisions.Create(rev)
+}
+
+// newRevision builds the snapshot and diff for a write without storing it.
+func newRevision(action string, actorID string, before *models.Item, item *models.Item) (*models.ItemRevision, error) {
+	after := models.SnapshotOf(item)
+	snapshot, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := &models.ItemRevision{
+		ItemID:   item.ID,
+		Action:   action,
+		ActorID:  actorID,
+		Snapshot: snapshot,
+	}
+
+	if before != nil {
+		if diff := models.SnapshotOf(before).Diff(after); len(diff) > 0 {
+			changes, err := json.Marshal(diff)
+			if err != nil {
+				return nil, err
+			}
+			rev.Changes = changes
+		}
+	}
+
+	return rev, nil
+}
+
+func (h *ItemHandler) History(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	itemID := c.Params("id")
+	item, err := h.itemRepo.GetByID(itemID)
+	if err != nil {
+		item, err = h.itemRepo.GetTrashedByID(itemID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+
+	if !authorizeItem(c, h.shares, item, userID, accessViewer, "access") {
+		return nil
+	}
+
+	revisions, err := h.revisions.ListByItem(itemID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching item history"})
+	}
+	if revisions == nil {
+		revisions = []models.ItemRevision{}
+	}
+
+	return c.JSON(revisions)
+}
+
+// Revert restores the item's editable fields to the snapshot stored in
+// :revision. The revert itself is recorded as a new revision.
+func (h *ItemHandler) Revert(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "revision must be a positive integer"})
+	}
+
+	itemID := c.Params("id")
+	item, err := h.itemRepo.GetByID(itemID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+
+	if !authorizeItem(c, h.shares, item, userID, accessEditor, "update") {
+		return nil
+	}
+
+	if !ifMatchSatisfied(c, item) {
+		return preconditionFailed(c)
+	}
+
+	rev, err := h.revisions.Get(itemID, revision)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Revision not found"})
+	}
+
+	var snapshot models.ItemSnapshot
+	if err := json.Unmarshal(rev.Snapshot, &snapshot); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error reading revision"})
+	}
+
+	before := *item
+	snapshot.ApplyTo(item)
+
+	err = h.transaction(func(tx itemTx) error {
+		if err := tx.Update(item); err != nil {
+			return err
+		}
+		return recordRevision(tx, models.RevisionActionRevert, userID, &before, item)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return preconditionFailed(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error reverting item"})
+	}
+
+	redactItemFor(item, userID)
+	setItemETag(c, item)
+	return c.JSON(item)
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+)
+
+const (
+	importBatchSize = 100
+	maxImportRows   = 10000
+)
+
+var errTooManyImportRows = fmt.Errorf("an import may contain at most %d rows", maxImportRows)
+
+// itemImportRow is one parsed input row. Only the fields a client may set on
+// creation are read; id and timestamps in exported files are ignored.
+type itemImportRow struct {
+	Line        int     `json:"-"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Completed   bool    `json:"completed"`
+}
+
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type importReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []importRowError `json:"errors"`
+}
+
+// Import creates items from a csv, json or ndjson document sent either as the
+// raw body or as the "file" field of a multipart form. Every row is validated
+// like a regular create; invalid rows are reported and skipped. With
+// dry_run=true nothing is written.
+func (h *ItemHandler) Import(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+
+	data, filename, err := readImportBody(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = detectImportFormat(c.Get(fiber.HeaderContentType), filename)
+	}
+
+	var (
+		rows      []itemImportRow
+		rowErrors []importRowError
+	)
+	switch format {
+	case "csv":
+		rows, rowErrors, err = parseCSVImport(data)
+	case "json":
+		rows, rowErrors, err = parseJSONImport(data)
+	case "ndjson":
+		rows, rowErrors, err = parseNDJSONImport(data)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be csv, json or ndjson"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + format + ": " + err.Error()})
+	}
+
+	items := make([]models.Item, 0, len(rows))
+	for _, row := range rows {
+		req := models.CreateItemRequest{
+			Title:       strings.TrimSpace(row.Title),
+			Description: row.Description,
+			Price:       row.Price,
+		}
+		if err := req.Validate(); err != nil {
+			rowErrors = append(rowErrors, importRowError{Row: row.Line, Error: err.Error()})
+			continue
+		}
+		items = append(items, models.Item{
+			UserID:      userID,
+			Title:       req.Title,
+			Description: req.Description,
+			Price:       req.Price,
+			Completed:   row.Completed,
+		})
+	}
+
+	sort.Slice(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+
+	report := importReport{
+		DryRun: dryRun,
+		Total:  len(items) + len(rowErrors),
+		Valid:  len(items),
+		Failed: len(rowErrors),
+		Errors: rowErrors,
+	}
+	if report.Errors == nil {
+		report.Errors = []importRowError{}
+	}
+
+	if dryRun || len(items) == 0 {
+		return c.JSON(report)
+	}
+
+	err = h.transaction(func(tx itemTx) error {
+		for start := 0; start < len(items); start += importBatchSize {
+			end := min(start+importBatchSize, len(items))
+			chunk := items[start:end]
+
+			if err := tx.CreateBatch(chunk, importBatchSize); err != nil {
+				return err
+			}
+
+			revs := make([]models.ItemRevision, 0, len(chunk))
+			for i := range chunk {
+				rev, err := newRevision(models.RevisionActionCreate, userID, nil, &chunk[i])
+				if err != nil {
+					return err
+				}
+				rev.Revision = 1
+				revs = append(revs, *rev)
+			}
+			if err := tx.revisions.CreateNumbered(revs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error importing items"})
+	}
+
+	report.Imported = len(items)
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+// readImportBody returns the uploaded document and, for multipart uploads,
+// its file name.
+func readImportBody(c fiber.Ctx) ([]byte, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if mediaType != fiber.MIMEMultipartForm {
+		if len(c.Body()) == 0 {
+			return nil, "", errors.New("request body is empty")
+		}
+		return c.Body(), "", nil
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, "", errors.New("multipart upload must contain a \"file\" field")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, fileHeader.Filename, nil
+}
+
+func detectImportFormat(contentType string, filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return "csv"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".json":
+		return "json"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/jsonl":
+		return "ndjson"
+	default:
+		return "json"
+	}
+}
+
+// parseCSVImport reads a CSV document whose first line names the columns.
+// Only "title" is required; rows are numbered as in the file, header = 1.
+// Text cells are unescaped the way Export escapes them, so an exported file
+// imports back unchanged.
+func parseCSVImport(data []byte) ([]itemImportRow, []importRowError, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.New("missing header row")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, nil, errors.New("header must contain a title column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var (
+		rows      []itemImportRow
+		rowErrors []importRowError
+	)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, importRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		if len(rows)+len(rowErrors) >= maxImportRows {
+			return nil, nil, errTooManyImportRows
+		}
+
+		row := itemImportRow{
+			Line:        line,
+			Title:       csvUnescapeCell(field(record, "title")),
+			Description: csvUnescapeCell(field(record, "description")),
+		}
+		if raw := field(record, "price"); raw != "" {
+			price, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				rowErrors = append(rowErrors, importRowError{Row: line, Error: "price must be a number"})
+				continue
+			}
+			row.Price = price
+		}
+		if raw := field(record, "completed"); raw != "" {
+			completed, err := strconv.ParseBool(raw)
+			if err != nil {
+				rowErrors = append(rowErrors, importRowError{Row: line, Error: "completed must be true or false"})
+				continue
+			}
+			row.Completed = completed
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// parseJSONImport reads a JSON array of item objects; rows are 1-based
+// array positions.
+func parseJSONImport(data []byte) ([]itemImportRow, []importRowError, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+	if len(raw) > maxImportRows {
+		return nil, nil, errTooManyImportRows
+	}
+
+	var (
+		rows      []itemImportRow
+		rowErrors []importRowError
+	)
+	for i, element := range raw {
+		var row itemImportRow
+		if err := json.Unmarshal(element, &row); err != nil {
+			rowErrors = append(rowErrors, importRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		row.Line = i + 1
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// parseNDJSONImport reads one JSON object per line, skipping blank lines;
+// rows are line numbers.
+func parseNDJSONImport(data []byte) ([]itemImportRow, []importRowError, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var (
+		rows      []itemImportRow
+		rowErrors []importRowError
+	)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows)+len(rowErrors) >= maxImportRows {
+			return nil, nil, errTooManyImportRows
+		}
+
+		var row itemImportRow
+		if err := json.Unmarshal(text, &row); err != nil {
+			rowErrors = append(rowErrors, importRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return rows, rowErrors, nil
+}
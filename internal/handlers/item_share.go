@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+// WithProfiles lets Share check that the grantee is a known user. Every
+// user has a profile row, provisioned on sign-up or their first request.
+func (h *ItemHandler) WithProfiles(profiles repository.ProfileRepository) *ItemHandler {
+	h.profiles = profiles
+	return h
+}
+
+// Share grants (or changes) a viewer or editor role on the item to another user.
+func (h *ItemHandler) Share(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	item, err := h.itemRepo.GetByID(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+
+	if !authorizeItem(c, h.shares, item, userID, accessOwner, "share") {
+		return nil
+	}
+
+	var req models.ShareItemRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+	}
+	if req.UserID == item.UserID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "The owner already has full access to this item"})
+	}
+	if h.profiles != nil {
+		grantee, err := h.profiles.GetByID(req.UserID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error sharing item"})
+		}
+		if grantee == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+	}
+
+	share := &models.ItemShare{
+		ItemID:    item.ID,
+		GranteeID: req.UserID,
+		Role:      req.Role,
+		GrantedBy: userID,
+	}
+	if err := h.shares.Upsert(share); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error sharing item"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(share)
+}
+
+func (h *ItemHandler) ListShares(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	item, err := h.itemRepo.GetByID(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+
+	if !authorizeItem(c, h.shares, item, userID, accessOwner, "share") {
+		return nil
+	}
+
+	shares, err := h.shares.ListByItem(item.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching shares"})
+	}
+	if shares == nil {
+		shares = []models.ItemShare{}
+	}
+
+	return c.JSON(shares)
+}
+
+func (h *ItemHandler) RevokeShare(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	item, err := h.itemRepo.GetByID(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+
+	if !authorizeItem(c, h.shares, item, userID, accessOwner, "share") {
+		return nil
+	}
+
+	if err := h.shares.Delete(item.ID, c.Params("userId")); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Share not found"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Share revoked successfully"})
+}
+
+// SharedWithMe lists items other users have shared with the caller.
+func (h *ItemHandler) SharedWithMe(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	items, err := h.shares.ListSharedWith(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching shared items"})
+	}
+	if items == nil {
+		items = []repository.SharedItem{}
+	}
+
+	return c.JSON(items)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+)
+
+// trashedItemResponse exposes the deletion timestamp, which models.Item hides.
+type trashedItemResponse struct {
+	models.Item
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func newTrashedItemResponse(item models.Item) trashedItemResponse {
+	resp := trashedItemResponse{Item: item}
+	if item.DeletedAt.Valid {
+		deletedAt := item.DeletedAt.Time
+		resp.DeletedAt = &deletedAt
+	}
+	return resp
+}
+
+func (h *ItemHandler) Trash(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	items, err := h.itemRepo.ListTrash(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching trash"})
+	}
+
+	response := make([]trashedItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, newTrashedItemResponse(item))
+	}
+
+	return c.JSON(response)
+}
+
+func (h *ItemHandler) Restore(c fiber.Ctx) error {
+	userID := h.requireAuth(c)
+	if userID == "" {
+		return nil
+	}
+
+	itemID := c.Params("id")
+	item, err := h.itemRepo.GetTrashedByID(itemID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found in trash"})
+	}
+
+	if !authorizeItem(c, h.shares, item, userID, accessOwner, "restore") {
+		return nil
+	}
+
+	var restored *models.Item
+	err = h.transaction(func(tx itemTx) error {
+		if err := tx.Restore(itemID); err != nil {
+			return err
+		}
+		found, err := tx.GetByID(itemID)
+		if err != nil {
+			return err
+		}
+		restored = found
+		return recordRevision(tx, models.RevisionActionRestore, userID, item, restored)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error restoring item"})
+	}
+
+	setItemETag(c, restored)
+	return c.JSON(restored)
+}
+
+// purge hard-deletes an item, whether it is live or already in the trash.
+// It backs DELETE /items/:id?permanent=true.
+func (h *ItemHandler) purge(c fiber.Ctx, userID string) error {
+	itemID := c.Params("id")
+	item, err := h.itemRepo.GetByID(itemID)
+	if err != nil {
+		item, err = h.itemRepo.GetTrashedByID(itemID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+
+	if !authorizeItem(c, h.shares, item, userID, accessOwner, "delete") {
+		return nil
+	}
+
+	if !ifMatchSatisfied(c, item) {
+		return preconditionFailed(c)
+	}
+
+	var attachments []models.ItemAttachment
+	if h.attachments != nil {
+		attachments, err = h.attachments.ListByItem(itemID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error deleting item"})
+		}
+	}
+
+	if err := h.itemRepo.Purge(itemID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error deleting item"})
+	}
+
+	if h.blobs != nil {
+		for _, att := range attachments {
+			if err := h.blobs.Delete(c.Context(), att.StorageKey); err != nil {
+				log.Printf("Failed to delete attachment blob %s: %v", att.StorageKey, err)
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Item permanently deleted"})
+}
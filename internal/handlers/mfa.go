@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+type enrollFactorRequest struct {
+	FriendlyName string `json:"friendlyName"`
+	Issuer       string `json:"issuer"`
+}
+
+type verifyFactorRequest struct {
+	ChallengeID string `json:"challengeId"`
+	Code        string `json:"code"`
+}
+
+// requireToken returns the caller's access token, which the factor endpoints
+// forward to Supabase.
+func requireToken(c fiber.Ctx) string {
+	token, ok := c.Locals("token").(string)
+	if !ok || token == "" {
+		c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+		return ""
+	}
+	return token
+}
+
+// EnrollMFA starts TOTP enrollment and returns the secret and QR code for the
+// user's authenticator app. The factor stays unverified until VerifyMFA.
+func (h *AuthHandler) EnrollMFA(c fiber.Ctx) error {
+	token := requireToken(c)
+	if token == "" {
+		return nil
+	}
+
+	var req enrollFactorRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	factor, err := h.client.EnrollFactor(c.Context(), token, supabase.EnrollFactorRequest{
+		FactorType:   supabase.FactorTypeTOTP,
+		FriendlyName: req.FriendlyName,
+		Issuer:       req.Issuer,
+	})
+	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	response := fiber.Map{
+		"id":   factor.ID,
+		"type": factor.Type,
+	}
+	if factor.TOTP != nil {
+		response["qrCode"] = factor.TOTP.QRCode
+		response["secret"] = factor.TOTP.Secret
+		response["uri"] = factor.TOTP.URI
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (h *AuthHandler) ChallengeMFA(c fiber.Ctx) error {
+	token := requireToken(c)
+	if token == "" {
+		return nil
+	}
+
+	challenge, err := h.client.ChallengeFactor(c.Context(), token, c.Params("id"))
+	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"challengeId": challenge.ID,
+		"expiresAt":   challenge.ExpiresAt,
+	})
+}
+
+// VerifyMFA answers a challenge with a TOTP code. It returns a new aal2
+// session, which clients must use in place of the current one.
+func (h *AuthHandler) VerifyMFA(c fiber.Ctx) error {
+	token := requireToken(c)
+	if token == "" {
+		return nil
+	}
+
+	var req verifyFactorRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.ChallengeID == "" || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "challengeId and code are required",
+		})
+	}
+
+	response, err := h.client.VerifyFactor(c.Context(), token, c.Params("id"), req.ChallengeID, req.Code)
+	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(newAuthResponse("Verificação concluída com sucesso", response))
+}
+
+// UnenrollMFA removes a factor. Verified factors need an aal2 session; a
+// factor whose enrollment was never completed can be removed at aal1.
+func (h *AuthHandler) UnenrollMFA(c fiber.Ctx) error {
+	token := requireToken(c)
+	if token == "" {
+		return nil
+	}
+	claims, _ := c.Locals("claims").(supabase.AccessTokenClaims)
+	factorID := c.Params("id")
+
+	if !claims.HasAAL(supabase.AAL2) {
+		factors, err := h.client.ListFactors(c.Context(), token)
+		if err != nil {
+			return respondSupabaseError(c, err)
+		}
+		if !isUnverifiedFactor(factors, factorID) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":        "Multi-factor authentication required",
+				"required_aal": supabase.AAL2,
+			})
+		}
+	}
+
+	if err := h.client.UnenrollFactor(c.Context(), token, factorID); err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Fator removido com sucesso",
+	})
+}
+
+func isUnverifiedFactor(factors []supabase.Factor, id string) bool {
+	for _, factor := range factors {
+		if factor.ID == id {
+			return factor.Status == supabase.FactorStatusUnverified
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/google"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+const (
+	oauthStateTTL      = 10 * time.Minute
+	oauthBindingCookie = "oauth_binding"
+	oauthCookiePath    = "/api/auth/oauth"
+)
+
+// WithGoogleOAuth enables Google sign-in. states keeps the PKCE verifier and
+// nonce of flows in progress.
+func (h *AuthHandler) WithGoogleOAuth(client *google.OAuthClient, states repository.OAuthStateStore) *AuthHandler {
+	h.google = client
+	h.oauthStates = states
+	return h
+}
+
+// GoogleOAuthStart redirects the browser to Google's consent screen. The flow
+// is protected three ways: the state parameter is single-use and bound to a
+// random HttpOnly cookie (CSRF), the code can only be redeemed with the PKCE
+// verifier kept server-side, and the ID token must carry our nonce (replay).
+func (h *AuthHandler) GoogleOAuthStart(c fiber.Ctx) error {
+	if h.google == nil || !h.google.Enabled() || h.oauthStates == nil {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
+			"error": "Google sign-in is not configured",
+		})
+	}
+
+	state, err1 := randomToken()
+	verifier, err2 := randomToken()
+	nonce, err3 := randomToken()
+	binding, err4 := randomToken()
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start Google sign-in",
+		})
+	}
+
+	err := h.oauthStates.Save(state, repository.OAuthState{
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		BindingHash:  sha256Hex(binding),
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	})
+	if errors.Is(err, repository.ErrTooManyOAuthStates) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Too many sign-ins in progress, try again later",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start Google sign-in",
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthBindingCookie,
+		Value:    binding,
+		Path:     oauthCookiePath,
+		MaxAge:   int(oauthStateTTL.Seconds()),
+		Secure:   strings.HasPrefix(h.google.RedirectURL(), "https://"),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	challenge := sha256.Sum256([]byte(verifier))
+	authURL := h.google.AuthCodeURL(state, base64.RawURLEncoding.EncodeToString(challenge[:]), sha256Hex(nonce))
+
+	return c.Redirect().Status(fiber.StatusFound).To(authURL)
+}
+
+// GoogleOAuthCallback finishes the flow: it checks state and cookie, redeems
+// the code with Google and trades the ID token for a Supabase session.
+func (h *AuthHandler) GoogleOAuthCallback(c fiber.Ctx) error {
+	if h.google == nil || !h.google.Enabled() || h.oauthStates == nil {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
+			"error": "Google sign-in is not configured",
+		})
+	}
+
+	binding := c.Cookies(oauthBindingCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthBindingCookie,
+		Value:    "",
+		Path:     oauthCookiePath,
+		MaxAge:   -1,
+		Secure:   strings.HasPrefix(h.google.RedirectURL(), "https://"),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Google sign-in was not completed: " + providerErr,
+		})
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" || binding == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid OAuth callback",
+		})
+	}
+
+	// Consume before any other check so a state can never be tried twice.
+	entry, ok, err := h.oauthStates.Consume(state)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to complete Google sign-in",
+		})
+	}
+	if !ok || subtle.ConstantTimeCompare([]byte(entry.BindingHash), []byte(sha256Hex(binding))) != 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or expired OAuth state",
+		})
+	}
+
+	token, err := h.google.Exchange(c.Context(), code, entry.CodeVerifier)
+	if err != nil {
+		log.Printf("Google code exchange failed: %v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Google sign-in failed",
+		})
+	}
+
+	response, err := h.client.SignInWithIDToken(c.Context(), supabase.IDTokenCredentials{
+		Provider:    "google",
+		IDToken:     token.IDToken,
+		AccessToken: token.AccessToken,
+		Nonce:       entry.Nonce,
+	})
+	if err != nil {
+		return respondSupabaseError(c, err)
+	}
+
+	return c.JSON(newAuthResponse("Login realizado com sucesso", response))
+}
+
+// randomToken returns 32 random bytes, base64url encoded.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
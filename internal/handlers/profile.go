@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// UpdateProfile replaces the caller's username, phone and profileUrl (PUT).
+func (h *AuthHandler) UpdateProfile(c fiber.Ctx) error {
+	return h.saveProfile(c, true)
+}
+
+// PatchProfile changes only the fields present in the body (PATCH).
+func (h *AuthHandler) PatchProfile(c fiber.Ctx) error {
+	return h.saveProfile(c, false)
+}
+
+// saveProfile writes the profile row and mirrors it into the Supabase
+// user_metadata, which is where AccessTokenClaims.Username() reads from once
+// the client refreshes its token.
+func (h *AuthHandler) saveProfile(c fiber.Ctx, replace bool) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+	token := requireToken(c)
+	if token == "" {
+		return nil
+	}
+	if h.profileRepo == nil {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
+			"error": "Profiles are not available",
+		})
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Normalize()
+	if err := req.Validate(replace); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	profile := h.loadOrNewProfile(c, userID)
+	if profile == nil {
+		return nil
+	}
+
+	if req.Username != nil && *req.Username != "" {
+		taken, err := h.profileRepo.UsernameTaken(*req.Username, userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to check username",
+			})
+		}
+		if taken {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": repository.ErrUsernameTaken.Error(),
+			})
+		}
+	}
+
+	req.ApplyTo(profile, replace)
+	if err := h.profileRepo.Save(profile); err != nil {
+		if errors.Is(err, repository.ErrUsernameTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to save profile",
+		})
+	}
+
+	// The profile row is the source of truth and is already committed, so a
+	// failed mirror does not fail the request. mirrored=false tells the client
+	// that token claims will lag until it repeats the (idempotent) update.
+	mirrored := h.mirrorProfile(c, token, profile) == nil
+
+	return c.JSON(fiber.Map{
+		"message":  "Perfil atualizado com sucesso",
+		"user":     h.profileUserResponse(c, profile),
+		"mirrored": mirrored,
+	})
+}
+
+// loadOrNewProfile returns the caller's profile, or an empty one with their ID
+// when they have none yet. It writes an error response and returns nil on
+// failure.
+func (h *AuthHandler) loadOrNewProfile(c fiber.Ctx, userID string) *models.Profile {
+	profile, err := h.profileRepo.GetByID(userID)
+	if err != nil {
+		_ = c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load profile",
+		})
+		return nil
+	}
+	if profile != nil {
+		return profile
+	}
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+		return nil
+	}
+	return &models.Profile{ID: id}
+}
+
+// mirrorProfile copies the profile fields into the Supabase user_metadata.
+// Private avatars are left out: their stored path is no link, and a signed
+// URL would expire in the metadata. Failures are logged here; callers only
+// report them.
+func (h *AuthHandler) mirrorProfile(c fiber.Ctx, token string, profile *models.Profile) error {
+	profileURL := profile.ProfileUrl
+	if isAvatarObjectPath(profileURL) {
+		profileURL = ""
+	}
+	_, err := h.client.UpdateUser(c.Context(), token, supabase.UserAttributes{
+		Data: map[string]interface{}{
+			"username":    profile.Username,
+			"phone":       profile.Phone,
+			"profile_url": profileURL,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to mirror profile %s to Supabase: %v", profile.ID, err)
+	}
+	return err
+}
+
+func (h *AuthHandler) profileUserResponse(c fiber.Ctx, profile *models.Profile) userResponse {
+	email, _ := c.Locals("email").(string)
+	return userResponse{
+		ID:         profile.ID.String(),
+		Username:   profile.Username,
+		Email:      email,
+		Phone:      profile.Phone,
+		ProfileUrl: h.profileURL(c, profile.ProfileUrl),
+	}
+}
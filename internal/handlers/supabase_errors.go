@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// supabaseErrorCodes maps Supabase Auth error codes clients are expected to
+// handle to the status and message this API returns for them.
+var supabaseErrorCodes = map[string]struct {
+	status  int
+	message string
+}{
+	"weak_password":                 {fiber.StatusUnprocessableEntity, "Password does not meet the strength requirements"},
+	"same_password":                 {fiber.StatusUnprocessableEntity, "New password must be different from the current one"},
+	"reauthentication_needed":       {fiber.StatusUnauthorized, "Recent sign-in required; request a reauthentication code and send it as nonce"},
+	"reauthentication_not_valid":    {fiber.StatusUnauthorized, "Reauthentication code is invalid or expired"},
+	"email_address_invalid":         {fiber.StatusBadRequest, "Email address is invalid"},
+	"over_email_send_rate_limit":    {fiber.StatusTooManyRequests, "Too many emails sent, try again later"},
+	"over_sms_send_rate_limit":      {fiber.StatusTooManyRequests, "Too many messages sent, try again later"},
+	"over_request_rate_limit":       {fiber.StatusTooManyRequests, "Too many requests, try again later"},
+	"otp_expired":                   {fiber.StatusUnauthorized, "Code is invalid or has expired"},
+	"otp_disabled":                  {fiber.StatusForbidden, "Passwordless sign-in is disabled"},
+	"invalid_credentials":           {fiber.StatusUnauthorized, "Invalid email or password"},
+	"email_not_confirmed":           {fiber.StatusForbidden, "Email address has not been confirmed"},
+	"refresh_token_not_found":       {fiber.StatusUnauthorized, "Session has expired, sign in again"},
+	"refresh_token_already_used":    {fiber.StatusUnauthorized, "Session has expired, sign in again"},
+	"session_not_found":             {fiber.StatusUnauthorized, "Session has expired, sign in again"},
+	"bad_jwt":                       {fiber.StatusUnauthorized, "Session has expired, sign in again"},
+	"mfa_verification_failed":       {fiber.StatusUnauthorized, "Invalid verification code"},
+	"mfa_challenge_expired":         {fiber.StatusUnauthorized, "Challenge has expired, request a new one"},
+	"mfa_factor_not_found":          {fiber.StatusNotFound, "Factor not found"},
+	"too_many_enrolled_mfa_factors": {fiber.StatusUnprocessableEntity, "Maximum number of factors reached"},
+	"insufficient_aal":              {fiber.StatusForbidden, "Multi-factor authentication required"},
+}
+
+// supabaseErrorResponse picks the status and message to return for an error
+// from supabase.Client. Known error codes get a fixed message, other 4xx
+// responses keep Supabase's status and message, and anything else (5xx,
+// network failures) is reported as 502.
+func supabaseErrorResponse(err error) (int, string) {
+	var apiErr *supabase.APIError
+	if !errors.As(err, &apiErr) {
+		return fiber.StatusBadGateway, "Authentication service unavailable"
+	}
+
+	if known, ok := supabaseErrorCodes[apiErr.Code]; ok {
+		return known.status, known.message
+	}
+	if apiErr.Status == http.StatusTooManyRequests {
+		return fiber.StatusTooManyRequests, "Too many requests, try again later"
+	}
+	if apiErr.Status >= 400 && apiErr.Status < 500 {
+		return apiErr.Status, apiErr.Message
+	}
+	return fiber.StatusBadGateway, "Authentication service unavailable"
+}
+
+func respondSupabaseError(c fiber.Ctx, err error) error {
+	status, message := supabaseErrorResponse(err)
+	return c.Status(status).JSON(fiber.Map{
+		"error": message,
+	})
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+type TagHandler struct {
+	tagRepo  repository.TagRepository
+	itemRepo repository.ItemRepository
+	shares   repository.ItemShareRepository
+}
+
+func NewTagHandler(tagRepo repository.TagRepository, itemRepo repository.ItemRepository, shares repository.ItemShareRepository) *TagHandler {
+	return &TagHandler{
+		tagRepo:  tagRepo,
+		itemRepo: itemRepo,
+		shares:   shares,
+	}
+}
+
+func (h *TagHandler) GetAll(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	tags, err := h.tagRepo.ListByUser(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching tags"})
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	return c.JSON(tags)
+}
+
+func (h *TagHandler) Create(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	req, ok := h.bindTagRequest(c, userID, "")
+	if !ok {
+		return nil
+	}
+
+	tag := &models.Tag{
+		UserID: userID,
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+	if err := h.tagRepo.Create(tag); err != nil {
+		if errors.Is(err, repository.ErrTagNameTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A tag with this name already exists"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error creating tag"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(tag)
+}
+
+func (h *TagHandler) GetByID(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	tag, ok := h.loadOwnedTag(c, userID)
+	if !ok {
+		return nil
+	}
+
+	return c.JSON(tag)
+}
+
+func (h *TagHandler) Update(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	tag, ok := h.loadOwnedTag(c, userID)
+	if !ok {
+		return nil
+	}
+
+	req, ok := h.bindTagRequest(c, userID, tag.ID)
+	if !ok {
+		return nil
+	}
+
+	tag.Name = req.Name
+	tag.Color = req.Color
+	if err := h.tagRepo.Update(tag); err != nil {
+		if errors.Is(err, repository.ErrTagNameTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A tag with this name already exists"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error updating tag"})
+	}
+
+	return c.JSON(tag)
+}
+
+func (h *TagHandler) Delete(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	tag, ok := h.loadOwnedTag(c, userID)
+	if !ok {
+		return nil
+	}
+
+	if err := h.tagRepo.Delete(tag.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error deleting tag"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Tag deleted successfully"})
+}
+
+// AttachToItem adds the tags listed in the body to the item in :id.
+func (h *TagHandler) AttachToItem(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	item, err := h.itemRepo.GetByID(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+	if !authorizeItem(c, h.shares, item, userID, accessOwner, "update") {
+		return nil
+	}
+
+	var req models.AttachTagsRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+	}
+	if len(req.TagIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tag_ids must not be empty"})
+	}
+
+	tags, err := h.tagRepo.GetByIDs(userID, req.TagIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching tags"})
+	}
+	if len(tags) != len(uniqueStrings(req.TagIDs)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "One or more tags do not exist"})
+	}
+
+	if err := h.itemRepo.AttachTags(item.ID, tags); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error attaching tags"})
+	}
+
+	return h.respondWithItem(c, item.ID)
+}
+
+// DetachFromItem removes the tag in :tagId from the item in :id.
+func (h *TagHandler) DetachFromItem(c fiber.Ctx) error {
+	userID := requireUserID(c)
+	if userID == "" {
+		return nil
+	}
+
+	item, err := h.itemRepo.GetByID(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Item not found"})
+	}
+	if !authorizeItem(c, h.shares, item, userID, accessOwner, "update") {
+		return nil
+	}
+
+	if err := h.itemRepo.DetachTag(item.ID, c.Params("tagId")); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error detaching tag"})
+	}
+
+	return h.respondWithItem(c, item.ID)
+}
+
+func (h *TagHandler) respondWithItem(c fiber.Ctx, itemID string) error {
+	item, err := h.itemRepo.GetByID(itemID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching item"})
+	}
+
+	setItemETag(c, item)
+	return c.JSON(item)
+}
+
+func (h *TagHandler) loadOwnedTag(c fiber.Ctx, userID string) (*models.Tag, bool) {
+	tag, err := h.tagRepo.GetByID(c.Params("id"))
+	if err != nil {
+		_ = c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+		return nil, false
+	}
+	if tag.UserID != userID {
+		_ = c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You do not have permission to access this tag"})
+		return nil, false
+	}
+	return tag, true
+}
+
+// bindTagRequest parses and validates a tag body, rejecting names the user
+// already uses on another tag.
+func (h *TagHandler) bindTagRequest(c fiber.Ctx, userID string, tagID string) (models.TagRequest, bool) {
+	var req models.TagRequest
+	if err := c.Bind().Body(&req); err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+		return req, false
+	}
+
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		_ = c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid data: " + err.Error()})
+		return req, false
+	}
+
+	taken, err := h.tagRepo.NameTaken(userID, req.Name, tagID)
+	if err != nil {
+		_ = c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error validating tag"})
+		return req, false
+	}
+	if taken {
+		_ = c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A tag with this name already exists"})
+		return req, false
+	}
+
+	return req, true
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+// TrashPurger periodically hard-deletes items that have been in the trash for
+// longer than the retention window.
+type TrashPurger struct {
+	itemRepo  repository.ItemRepository
+	blobs     repository.BlobStore
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashPurger(itemRepo repository.ItemRepository, retention time.Duration, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		itemRepo:  itemRepo,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// WithBlobStore makes the purger also delete the attachment files of purged
+// items from blobs.
+func (p *TrashPurger) WithBlobStore(blobs repository.BlobStore) *TrashPurger {
+	p.blobs = blobs
+	return p
+}
+
+// Start runs the purger until ctx is cancelled. It returns immediately when
+// retention or interval is not positive.
+func (p *TrashPurger) Start(ctx context.Context) {
+	if p.retention <= 0 || p.interval <= 0 {
+		log.Println("Trash purger disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		p.runOnce()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.runOnce()
+			}
+		}
+	}()
+}
+
+func (p *TrashPurger) runOnce() {
+	cutoff := time.Now().Add(-p.retention)
+
+	purged, storageKeys, err := p.itemRepo.PurgeDeletedBefore(cutoff)
+	if err != nil {
+		log.Printf("Trash purge failed: %v", err)
+		return
+	}
+	// Files go only after their rows are gone, and only those of the rows
+	// this purge removed; a failure here leaves an orphaned file rather than
+	// an attachment pointing at nothing.
+	if p.blobs != nil {
+		for _, key := range storageKeys {
+			if err := p.blobs.Delete(context.Background(), key); err != nil {
+				log.Printf("Failed to delete attachment blob %s: %v", key, err)
+			}
+		}
+	}
+	if purged > 0 {
+		log.Printf("Trash purge removed %d items deleted before %s", purged, cutoff.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/l-fraga2811/back-sable/internal/repository"
+)
+
+type purgeItemRepo struct {
+	repository.ItemRepository
+	storageKeys []string
+	err         error
+}
+
+func (r *purgeItemRepo) PurgeDeletedBefore(cutoff time.Time) (int64, []string, error) {
+	if r.err != nil {
+		return 0, nil, r.err
+	}
+	return int64(len(r.storageKeys)), r.storageKeys, nil
+}
+
+type recordingBlobStore struct {
+	repository.BlobStore
+	deleted []string
+}
+
+func (s *recordingBlobStore) Delete(ctx context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestPurgerDeletesOnlyBlobsOfPurgedRows(t *testing.T) {
+	blobs := &recordingBlobStore{}
+	repo := &purgeItemRepo{storageKeys: []string{"items/1/a", "items/2/b"}}
+
+	NewTrashPurger(repo, time.Hour, time.Hour).WithBlobStore(blobs).runOnce()
+
+	if len(blobs.deleted) != 2 || blobs.deleted[0] != "items/1/a" || blobs.deleted[1] != "items/2/b" {
+		t.Fatalf("deleted = %v, want the purged rows' keys", blobs.deleted)
+	}
+}
+
+func TestPurgerKeepsBlobsWhenPurgeFails(t *testing.T) {
+	blobs := &recordingBlobStore{}
+	repo := &purgeItemRepo{storageKeys: []string{"items/1/a"}, err: errors.New("connection reset")}
+
+	NewTrashPurger(repo, time.Hour, time.Hour).WithBlobStore(blobs).runOnce()
+
+	if len(blobs.deleted) != 0 {
+		t.Fatalf("deleted = %v, want none", blobs.deleted)
+	}
+}
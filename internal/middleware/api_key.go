@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// lastUsedGranularity limits how often a key's last_used_at is written.
+const lastUsedGranularity = time.Minute
+
+// AuthMiddleware accepts either a Supabase JWT or an API key
+// ("Authorization: Bearer sk_...") and sets the same user locals for both.
+// API key requests carry no "token" or "claims" locals, so endpoints that
+// call Supabase on the user's behalf or need MFA reject them.
+func AuthMiddleware(validator *supabase.TokenValidator, revocations repository.TokenRevocationStore, apiKeys repository.APIKeyRepository) fiber.Handler {
+	jwtAuth := SupabaseAuthMiddleware(validator, revocations)
+
+	return func(c fiber.Ctx) error {
+		secret, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+		if !ok || apiKeys == nil || !strings.HasPrefix(secret, models.APIKeyPrefix) {
+			return jwtAuth(c)
+		}
+
+		key, err := apiKeys.GetByHash(models.HashAPIKey(secret))
+		if err != nil {
+			log.Printf("API key lookup failed: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Unable to verify API key",
+			})
+		}
+		now := time.Now()
+		if key == nil || !key.Active(now) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid, expired or revoked API key",
+			})
+		}
+
+		if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedGranularity {
+			if err := apiKeys.TouchLastUsed(key.ID, now); err != nil {
+				log.Printf("Failed to record API key use: %v", err)
+			}
+		}
+
+		c.Locals("userID", key.UserID)
+		c.Locals("email", "")
+		c.Locals("username", "")
+		c.Locals("principal", Principal{
+			UserID:   key.UserID,
+			APIKeyID: key.ID,
+			Scopes:   key.Scopes,
+		})
+
+		return c.Next()
+	}
+}
+
+// RequireScopes checks API key scopes for a resource: safe methods need
+// "<resource>:read" and everything else "<resource>:write". Requests
+// authenticated with a JWT are not scope-limited.
+func RequireScopes(resource string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		p, ok := PrincipalFrom(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not authenticated",
+			})
+		}
+
+		scope := resource + ":write"
+		switch c.Method() {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = resource + ":read"
+		}
+
+		if !p.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "API key is missing scope " + scope,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// RequireSessionAuth rejects API keys, for endpoints such as key management
+// that only an interactive session may use.
+func RequireSessionAuth(c fiber.Ctx) error {
+	p, ok := PrincipalFrom(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+	if p.APIKeyID != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "API keys cannot be used for this endpoint",
+		})
+	}
+	return c.Next()
+}
@@ -1,14 +1,17 @@
 package middleware
 
 import (
+	"log"
 	"strings"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/repository"
 	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
 )
 
-// SupabaseAuthMiddleware checks for a valid Supabase JWT token
-func SupabaseAuthMiddleware(validator *supabase.TokenValidator) fiber.Handler {
+// SupabaseAuthMiddleware checks for a valid Supabase JWT token that has not
+// been revoked through logout. revocations may be nil to skip that check.
+func SupabaseAuthMiddleware(validator *supabase.TokenValidator, revocations repository.TokenRevocationStore) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -28,17 +31,54 @@ func SupabaseAuthMiddleware(validator *supabase.TokenValidator) fiber.Handler {
 
 		claims, err := validator.Validate(tokenString)
 		if err != nil {
+			log.Printf("%s %s: %v", c.Method(), c.Path(), err)
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid or expired token",
 			})
 		}
 
+		if revocations != nil {
+			revoked, err := isRevoked(revocations, claims)
+			if err != nil {
+				log.Printf("Token revocation check failed: %v", err)
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"error": "Unable to verify token",
+				})
+			}
+			if revoked {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Token has been revoked",
+				})
+			}
+		}
+
 		// Set user context
 		c.Locals("userID", claims.Subject)
 		c.Locals("email", claims.Email)
 		c.Locals("username", claims.Username())
 		c.Locals("token", tokenString)
+		c.Locals("claims", claims)
+		c.Locals("principal", NewPrincipal(claims))
 
 		return c.Next()
 	}
 }
+
+func isRevoked(revocations repository.TokenRevocationStore, claims supabase.AccessTokenClaims) (bool, error) {
+	if key := claims.RevocationKey(); key != "" {
+		revoked, err := revocations.IsSessionRevoked(key)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	cutoff, ok, err := revocations.UserRevokedBefore(claims.Subject)
+	if err != nil || !ok {
+		return false, err
+	}
+	// Tokens without iat cannot prove they were issued after the cutoff.
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	return claims.IssuedAt.Before(cutoff), nil
+}
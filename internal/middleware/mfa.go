@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// RequireAAL rejects requests whose session has not reached level, e.g.
+// supabase.AAL2 for routes that need a verified second factor. It must run
+// after SupabaseAuthMiddleware.
+func RequireAAL(level string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		claims, ok := c.Locals("claims").(supabase.AccessTokenClaims)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not authenticated",
+			})
+		}
+
+		if !claims.HasAAL(level) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":        "Multi-factor authentication required",
+				"required_aal": level,
+			})
+		}
+
+		return c.Next()
+	}
+}
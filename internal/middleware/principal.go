@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// Application roles, granted through app_metadata.role or app_metadata.roles.
+const (
+	RoleAdmin = "admin"
+)
+
+// Permissions checked by RequirePermission. They can be granted directly in
+// app_metadata.permissions or implied by a role.
+const (
+	PermissionItemsReadAny   = "items:read:any"
+	PermissionItemsWriteAny  = "items:write:any"
+	PermissionItemsDeleteAny = "items:delete:any"
+)
+
+// rolePermissions lists the permissions each application role implies.
+var rolePermissions = map[string][]string{
+	RoleAdmin: {PermissionItemsReadAny, PermissionItemsWriteAny, PermissionItemsDeleteAny},
+}
+
+// Principal is the authenticated caller, stored in the "principal" local.
+// TokenRole is the JWT role claim ("authenticated" for normal users); Roles
+// and Permissions come from app_metadata, which users cannot edit themselves.
+// For API keys, APIKeyID is set and Scopes limits what the key may do.
+type Principal struct {
+	UserID      string
+	Email       string
+	TokenRole   string
+	Roles       []string
+	Permissions []string
+	APIKeyID    string
+	Scopes      []string
+}
+
+// NewPrincipal derives the caller's roles and permissions from token claims.
+func NewPrincipal(claims supabase.AccessTokenClaims) Principal {
+	p := Principal{
+		UserID:    claims.Subject,
+		Email:     claims.Email,
+		TokenRole: claims.Role,
+	}
+
+	roles := metadataStrings(claims.AppMetadata, "roles")
+	roles = append(roles, metadataStrings(claims.AppMetadata, "role")...)
+	p.Roles = dedupe(roles)
+
+	permissions := metadataStrings(claims.AppMetadata, "permissions")
+	for _, role := range p.Roles {
+		permissions = append(permissions, rolePermissions[role]...)
+	}
+	p.Permissions = dedupe(permissions)
+
+	return p
+}
+
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Principal) HasPermission(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether an API key was granted scope. Sessions are not
+// limited by scope.
+func (p Principal) HasScope(scope string) bool {
+	if p.APIKeyID == "" {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalFrom returns the principal stored by SupabaseAuthMiddleware.
+func PrincipalFrom(c fiber.Ctx) (Principal, bool) {
+	p, ok := c.Locals("principal").(Principal)
+	return p, ok
+}
+
+// RequireRole lets the request through only if the caller has any of roles.
+// It must run after SupabaseAuthMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		p, ok := PrincipalFrom(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not authenticated",
+			})
+		}
+
+		for _, role := range roles {
+			if p.HasRole(role) {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Insufficient role",
+		})
+	}
+}
+
+// RequirePermission lets the request through only if the caller holds every
+// one of permissions. It must run after SupabaseAuthMiddleware.
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		p, ok := PrincipalFrom(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not authenticated",
+			})
+		}
+
+		for _, permission := range permissions {
+			if !p.HasPermission(permission) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Missing permission " + permission,
+				})
+			}
+		}
+		return c.Next()
+	}
+}
+
+// metadataStrings reads key as either a single string or a list of strings.
+func metadataStrings(metadata map[string]interface{}, key string) []string {
+	switch value := metadata[key].(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []interface{}:
+		out := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"github.com/l-fraga2811/back-sable/internal/repository"
+	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
+)
+
+// ProvisionProfile creates the caller's profile row from their token's
+// user_metadata the first time they are seen, covering users who signed up
+// before profiles were provisioned or through OAuth/OTP. It must run after the
+// auth middleware and never fails the request.
+func ProvisionProfile(profiles repository.ProfileRepository) fiber.Handler {
+	// known holds user IDs whose profile is known to exist, so the database
+	// is only checked once per user and process.
+	var known sync.Map
+
+	return func(c fiber.Ctx) error {
+		userID, _ := c.Locals("userID").(string)
+		if profiles == nil || userID == "" {
+			return c.Next()
+		}
+		if _, ok := known.Load(userID); ok {
+			return c.Next()
+		}
+
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return c.Next()
+		}
+
+		var metadata map[string]interface{}
+		if claims, ok := c.Locals("claims").(supabase.AccessTokenClaims); ok {
+			metadata = claims.UserMetadata
+		}
+
+		profile := models.ProfileFromMetadata(id, metadata)
+		if _, err := profiles.Provision(&profile); err != nil {
+			log.Printf("Failed to provision profile for %s: %v", userID, err)
+			return c.Next()
+		}
+		known.Store(userID, struct{}{})
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key secret so the auth middleware can tell
+// keys from JWTs, and secret scanners can recognise leaked keys.
+const APIKeyPrefix = "sk_"
+
+// apiKeyDisplayLength is how much of the secret is kept in APIKey.Prefix.
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// Scopes an API key can be granted. A key may only use the routes its scopes
+// cover; JWT sessions are not restricted by scope.
+const (
+	ScopeItemsRead  = "items:read"
+	ScopeItemsWrite = "items:write"
+	ScopeTagsRead   = "tags:read"
+	ScopeTagsWrite  = "tags:write"
+)
+
+var validAPIKeyScopes = map[string]bool{
+	ScopeItemsRead:  true,
+	ScopeItemsWrite: true,
+	ScopeTagsRead:   true,
+	ScopeTagsWrite:  true,
+}
+
+const (
+	MaxAPIKeyNameLength = 100
+	// MaxAPIKeyLifetime bounds how far in the future a key may expire.
+	MaxAPIKeyLifetime = 365 * 24 * time.Hour
+)
+
+var (
+	ErrAPIKeyNameRequired  = errors.New("name is required")
+	ErrAPIKeyNameTooLong   = errors.New("name must be at most 100 characters")
+	ErrAPIKeyScopeRequired = errors.New("at least one scope is required")
+	ErrInvalidAPIKeyScope  = errors.New("scopes must be among items:read, items:write, tags:read, tags:write")
+	ErrAPIKeyExpiry        = errors.New("expires_at must be in the future and at most one year away")
+)
+
+// APIKey is a long-lived credential for scripts and CI jobs. Only the SHA-256
+// of the secret is stored; Prefix is kept so users can tell keys apart.
+type APIKey struct {
+	ID         string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string     `gorm:"type:text;not null" json:"name"`
+	Prefix     string     `gorm:"type:text;not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	Scopes     []string   `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// Active reports whether the key can still authenticate at now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
+
+// NewAPIKeySecret generates a key secret with 256 bits of entropy and returns
+// it along with the prefix to store for display.
+func NewAPIKeySecret() (secret string, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return secret, secret[:apiKeyDisplayLength], nil
+}
+
+// HashAPIKey is the value stored in APIKey.KeyHash for secret. The secret is
+// random, so a plain SHA-256 is enough; it need not be slow like a password
+// hash.
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string    `json:"name" validate:"required"`
+	Scopes    []string  `json:"scopes" validate:"required"`
+	ExpiresAt time.Time `json:"expires_at" validate:"required"`
+}
+
+func (r *CreateAPIKeyRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+}
+
+func (r CreateAPIKeyRequest) Validate(now time.Time) error {
+	if r.Name == "" {
+		return ErrAPIKeyNameRequired
+	}
+	if len([]rune(r.Name)) > MaxAPIKeyNameLength {
+		return ErrAPIKeyNameTooLong
+	}
+	if len(r.Scopes) == 0 {
+		return ErrAPIKeyScopeRequired
+	}
+	for _, scope := range r.Scopes {
+		if !validAPIKeyScopes[scope] {
+			return ErrInvalidAPIKeyScope
+		}
+	}
+	if !r.ExpiresAt.After(now) || r.ExpiresAt.After(now.Add(MaxAPIKeyLifetime)) {
+		return ErrAPIKeyExpiry
+	}
+	return nil
+}
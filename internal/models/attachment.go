@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ItemAttachment is a file attached to an item. The content lives in a blob
+// store under StorageKey; only metadata is kept in the database.
+type ItemAttachment struct {
+	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ItemID      string    `gorm:"type:uuid;not null;index" json:"item_id"`
+	UploadedBy  string    `gorm:"type:uuid;not null" json:"uploaded_by"`
+	Filename    string    `gorm:"type:text;not null" json:"filename"`
+	ContentType string    `gorm:"type:text;not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ItemAttachment) TableName() string {
+	return "item_attachments"
+}
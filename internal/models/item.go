@@ -1,6 +1,8 @@
 package models
 
 import (
+    "errors"
+    "strings"
     "time"
     "gorm.io/gorm"
 )
@@ -12,6 +14,8 @@ type Item struct {
     Description string         `gorm:"type:text" json:"description"`
     Price       float64        `gorm:"type:decimal(12,2)" json:"price"`
     Completed   bool           `gorm:"default:false" json:"completed"`
+    Version     int            `gorm:"not null;default:1" json:"version"`
+    Tags        []Tag          `gorm:"many2many:item_tags;constraint:OnDelete:CASCADE" json:"tags"`
     CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
     UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
     DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
@@ -21,15 +25,84 @@ func (Item) TableName() string {
     return "items"
 }
 
+var (
+    ErrTitleRequired = errors.New("title is required")
+    ErrNegativePrice = errors.New("price must not be negative")
+)
+
 type CreateItemRequest struct {
     Title       string  `json:"title" validate:"required"`
     Description string  `json:"description"`
     Price       float64 `json:"price"`
 }
 
+func (r CreateItemRequest) Validate() error {
+    if strings.TrimSpace(r.Title) == "" {
+        return ErrTitleRequired
+    }
+    if r.Price < 0 {
+        return ErrNegativePrice
+    }
+    return nil
+}
+
+// UpdateItemRequest is the body of PUT /items/:id. It replaces every editable
+// field, so omitted fields are reset to their zero value.
 type UpdateItemRequest struct {
-    Title       string  `json:"title,omitempty"`
-    Description string  `json:"description,omitempty"`
-    Price       float64 `json:"price,omitempty"`
-    Completed   bool    `json:"completed,omitempty"`
-}
\ No newline at end of file
+    Title       string  `json:"title" validate:"required"`
+    Description string  `json:"description"`
+    Price       float64 `json:"price"`
+    Completed   bool    `json:"completed"`
+}
+
+func (r UpdateItemRequest) Validate() error {
+    if strings.TrimSpace(r.Title) == "" {
+        return ErrTitleRequired
+    }
+    if r.Price < 0 {
+        return ErrNegativePrice
+    }
+    return nil
+}
+
+func (r UpdateItemRequest) ApplyTo(item *Item) {
+    item.Title = r.Title
+    item.Description = r.Description
+    item.Price = r.Price
+    item.Completed = r.Completed
+}
+
+// PatchItemRequest is the body of PATCH /items/:id. Only fields present in the
+// payload are changed, so a description can be cleared with "" and a price
+// set to 0.
+type PatchItemRequest struct {
+    Title       *string  `json:"title,omitempty"`
+    Description *string  `json:"description,omitempty"`
+    Price       *float64 `json:"price,omitempty"`
+    Completed   *bool    `json:"completed,omitempty"`
+}
+
+func (r PatchItemRequest) Validate() error {
+    if r.Title != nil && strings.TrimSpace(*r.Title) == "" {
+        return ErrTitleRequired
+    }
+    if r.Price != nil && *r.Price < 0 {
+        return ErrNegativePrice
+    }
+    return nil
+}
+
+func (r PatchItemRequest) ApplyTo(item *Item) {
+    if r.Title != nil {
+        item.Title = *r.Title
+    }
+    if r.Description != nil {
+        item.Description = *r.Description
+    }
+    if r.Price != nil {
+        item.Price = *r.Price
+    }
+    if r.Completed != nil {
+        item.Completed = *r.Completed
+    }
+}
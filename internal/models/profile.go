@@ -1,6 +1,10 @@
 package models
 
 import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,3 +22,143 @@ type Profile struct {
 func (Profile) TableName() string {
 	return "profiles"
 }
+
+const (
+	MinUsernameLength   = 3
+	MaxUsernameLength   = 30
+	MaxProfileURLLength = 2048
+)
+
+var (
+	ErrUsernameRequired = errors.New("username is required")
+	ErrInvalidUsername  = errors.New("username must be 3 to 30 letters, digits, '.', '_' or '-'")
+	ErrInvalidPhone     = errors.New("phone must be an international number such as +5511912345678")
+	ErrInvalidURL       = errors.New("profileUrl must be an absolute http or https URL")
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	e164Pattern     = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+	// phoneSeparators are stripped before checking a phone number.
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+)
+
+// ProfileFromMetadata builds the initial profile of a user from the
+// username, phone and profile_url sign-up metadata. Values that would not
+// pass UpdateProfileRequest validation are left empty.
+func ProfileFromMetadata(id uuid.UUID, metadata map[string]interface{}) Profile {
+	profile := Profile{ID: id}
+
+	if username, ok := metadata["username"].(string); ok {
+		username = strings.TrimSpace(username)
+		if ValidateUsername(username) == nil {
+			profile.Username = username
+		}
+	}
+	if phone, ok := metadata["phone"].(string); ok {
+		phone = NormalizePhone(phone)
+		if e164Pattern.MatchString(phone) {
+			profile.Phone = phone
+		}
+	}
+	if profileURL, ok := metadata["profile_url"].(string); ok {
+		profileURL = strings.TrimSpace(profileURL)
+		if profileURL != "" && validateProfileURL(profileURL) == nil {
+			profile.ProfileUrl = profileURL
+		}
+	}
+
+	return profile
+}
+
+// UpdateProfileRequest edits the caller's profile. For PATCH, nil fields are
+// left unchanged; for PUT they are cleared, except Username which is required.
+type UpdateProfileRequest struct {
+	Username   *string `json:"username"`
+	Phone      *string `json:"phone"`
+	ProfileUrl *string `json:"profileUrl"`
+}
+
+// Normalize trims the fields and rewrites Phone in E.164 form.
+func (r *UpdateProfileRequest) Normalize() {
+	if r.Username != nil {
+		username := strings.TrimSpace(*r.Username)
+		r.Username = &username
+	}
+	if r.Phone != nil {
+		phone := NormalizePhone(*r.Phone)
+		r.Phone = &phone
+	}
+	if r.ProfileUrl != nil {
+		profileURL := strings.TrimSpace(*r.ProfileUrl)
+		r.ProfileUrl = &profileURL
+	}
+}
+
+// Validate checks the request; replace is true for PUT.
+func (r UpdateProfileRequest) Validate(replace bool) error {
+	if r.Username == nil {
+		if replace {
+			return ErrUsernameRequired
+		}
+	} else if err := ValidateUsername(*r.Username); err != nil {
+		return err
+	}
+	if r.Phone != nil && *r.Phone != "" && !e164Pattern.MatchString(*r.Phone) {
+		return ErrInvalidPhone
+	}
+	if r.ProfileUrl != nil && *r.ProfileUrl != "" {
+		if err := validateProfileURL(*r.ProfileUrl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTo copies the request onto profile; replace is true for PUT.
+func (r UpdateProfileRequest) ApplyTo(profile *Profile, replace bool) {
+	if r.Username != nil {
+		profile.Username = *r.Username
+	}
+	if r.Phone != nil {
+		profile.Phone = *r.Phone
+	} else if replace {
+		profile.Phone = ""
+	}
+	if r.ProfileUrl != nil {
+		profile.ProfileUrl = *r.ProfileUrl
+	} else if replace {
+		profile.ProfileUrl = ""
+	}
+}
+
+func ValidateUsername(username string) error {
+	if username == "" {
+		return ErrUsernameRequired
+	}
+	if n := len(username); n < MinUsernameLength || n > MaxUsernameLength || !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	return nil
+}
+
+// NormalizePhone strips common separators and turns a leading international
+// "00" into "+". The result still needs to be checked against E.164.
+func NormalizePhone(phone string) string {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	return phone
+}
+
+func validateProfileURL(raw string) error {
+	if len(raw) > MaxProfileURLLength {
+		return ErrInvalidURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+	RevisionActionRevert  = "revert"
+)
+
+// ItemRevision records a single write to an item: who made it, the item's
+// editable fields afterwards and which of them changed.
+type ItemRevision struct {
+	ID        string          `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ItemID    string          `gorm:"type:uuid;not null;uniqueIndex:idx_item_revisions_item_revision" json:"item_id"`
+	Revision  int             `gorm:"not null;uniqueIndex:idx_item_revisions_item_revision" json:"revision"`
+	Action    string          `gorm:"type:text;not null" json:"action"`
+	ActorID   string          `gorm:"type:uuid;not null" json:"actor_id"`
+	Snapshot  json.RawMessage `gorm:"type:jsonb;not null" json:"snapshot"`
+	Changes   json.RawMessage `gorm:"type:jsonb" json:"changes,omitempty"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ItemRevision) TableName() string {
+	return "item_revisions"
+}
+
+// ItemSnapshot is the part of an item captured by a revision.
+type ItemSnapshot struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Completed   bool    `json:"completed"`
+}
+
+func SnapshotOf(item *Item) ItemSnapshot {
+	return ItemSnapshot{
+		Title:       item.Title,
+		Description: item.Description,
+		Price:       item.Price,
+		Completed:   item.Completed,
+	}
+}
+
+func (s ItemSnapshot) ApplyTo(item *Item) {
+	item.Title = s.Title
+	item.Description = s.Description
+	item.Price = s.Price
+	item.Completed = s.Completed
+}
+
+// FieldChange is one entry of a revision's field-level diff.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff lists the fields that differ between s and next, keyed by JSON name.
+func (s ItemSnapshot) Diff(next ItemSnapshot) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	if s.Title != next.Title {
+		changes["title"] = FieldChange{From: s.Title, To: next.Title}
+	}
+	if s.Description != next.Description {
+		changes["description"] = FieldChange{From: s.Description, To: next.Description}
+	}
+	if s.Price != next.Price {
+		changes["price"] = FieldChange{From: s.Price, To: next.Price}
+	}
+	if s.Completed != next.Completed {
+		changes["completed"] = FieldChange{From: s.Completed, To: next.Completed}
+	}
+	return changes
+}
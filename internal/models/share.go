@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ShareRoleViewer = "viewer"
+	ShareRoleEditor = "editor"
+)
+
+var (
+	ErrInvalidShareUser = errors.New("user_id must be a valid UUID")
+	ErrInvalidShareRole = errors.New("role must be viewer or editor")
+)
+
+// ItemShare grants a user other than the owner access to an item.
+type ItemShare struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ItemID    string    `gorm:"type:uuid;not null;uniqueIndex:idx_item_shares_item_grantee" json:"item_id"`
+	GranteeID string    `gorm:"type:uuid;not null;uniqueIndex:idx_item_shares_item_grantee;index" json:"user_id"`
+	Role      string    `gorm:"type:text;not null" json:"role"`
+	GrantedBy string    `gorm:"type:uuid;not null" json:"granted_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ItemShare) TableName() string {
+	return "item_shares"
+}
+
+type ShareItemRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required"`
+}
+
+func (r ShareItemRequest) Validate() error {
+	if _, err := uuid.Parse(r.UserID); err != nil {
+		return ErrInvalidShareUser
+	}
+	if r.Role != ShareRoleViewer && r.Role != ShareRoleEditor {
+		return ErrInvalidShareRole
+	}
+	return nil
+}
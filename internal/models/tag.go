@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const MaxTagNameLength = 50
+
+var (
+	ErrTagNameRequired = errors.New("name is required")
+	ErrTagNameTooLong  = errors.New("name must be at most 50 characters")
+	ErrInvalidTagColor = errors.New("color must be a hex value such as #1a2b3c")
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type Tag struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name      string    `gorm:"type:text;not null" json:"name"`
+	Color     string    `gorm:"type:text" json:"color"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+type TagRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Color string `json:"color"`
+}
+
+// Normalize trims surrounding whitespace from the request fields.
+func (r *TagRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Color = strings.TrimSpace(r.Color)
+}
+
+func (r TagRequest) Validate() error {
+	if r.Name == "" {
+		return ErrTagNameRequired
+	}
+	if len([]rune(r.Name)) > MaxTagNameLength {
+		return ErrTagNameTooLong
+	}
+	if r.Color != "" && !tagColorPattern.MatchString(r.Color) {
+		return ErrInvalidTagColor
+	}
+	return nil
+}
+
+type AttachTagsRequest struct {
+	TagIDs []string `json:"tag_ids"`
+}
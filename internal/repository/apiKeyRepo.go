@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/l-fraga2811/back-sable/internal/models"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	// GetByHash returns the key whose secret hashes to hash, or nil if none.
+	GetByHash(hash string) (*models.APIKey, error)
+	ListByUser(userID string) ([]models.APIKey, error)
+	// Revoke marks userID's key as revoked and reports whether it existed.
+	Revoke(id string, userID string, at time.Time) (bool, error)
+	TouchLastUsed(id string, at time.Time) error
+}
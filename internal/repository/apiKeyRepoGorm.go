@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepositoryGorm(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepositoryGorm{db: db}
+}
+
+func (r *apiKeyRepositoryGorm) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepositoryGorm) GetByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepositoryGorm) ListByUser(userID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepositoryGorm) Revoke(id string, userID string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *apiKeyRepositoryGorm) TouchLastUsed(id string, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBlobNotFound     = errors.New("blob not found")
+	ErrInvalidBlobKey   = errors.New("invalid blob key")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+	ErrPlaceholderKey   = errors.New("blob signing key is the example placeholder; set a secret or leave it empty")
+)
+
+// placeholderSigningKey is the value .env.example used to ship with. Anyone
+// could forge download links signed with it.
+const placeholderSigningKey = "change_me"
+
+// BlobStore keeps file contents, such as item attachments, outside the
+// database. Keys are slash-separated paths chosen by the caller.
+type BlobStore interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads key, as an attachment named
+	// filename, without further authentication until ttl elapses.
+	SignedURL(ctx context.Context, key string, filename string, ttl time.Duration) (string, error)
+}
+
+// LocalBlobStore keeps blobs on the local filesystem, for development. Its
+// signed URLs point at baseURL + LocalBlobPath and are verified by Open.
+type LocalBlobStore struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+// LocalBlobPath is the route that serves LocalBlobStore downloads.
+const LocalBlobPath = "/blobs/"
+
+// NewLocalBlobStore stores blobs under dir. An empty secret is replaced by a
+// random one, so signed URLs stop working when the process restarts.
+func NewLocalBlobStore(dir string, baseURL string, secret string) (*LocalBlobStore, error) {
+	if secret == placeholderSigningKey {
+		return nil, ErrPlaceholderKey
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LocalBlobStore{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  key,
+	}, nil
+}
+
+func (s *LocalBlobStore) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o640)
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) SignedURL(ctx context.Context, key string, filename string, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	q := url.Values{}
+	q.Set("expires", expires)
+	if filename != "" {
+		q.Set("name", filename)
+	}
+	q.Set("sig", s.sign(key, expires, filename))
+	return s.baseURL + LocalBlobPath + key + "?" + q.Encode(), nil
+}
+
+// Open checks a signed URL's expires, name and sig parameters and opens the
+// blob.
+func (s *LocalBlobStore) Open(key string, expires string, filename string, sig string) (*os.File, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix || !hmac.Equal([]byte(sig), []byte(s.sign(key, expires, filename))) {
+		return nil, ErrInvalidSignature
+	}
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+func (s *LocalBlobStore) sign(key string, expires string, filename string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	io.WriteString(mac, key+"\n"+expires+"\n"+filename)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps key into the store's directory, refusing keys that would escape
+// it.
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", ErrInvalidBlobKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
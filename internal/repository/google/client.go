@@ -0,0 +1,116 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/l-fraga2811/back-sable/internal/config"
+)
+
+const (
+	authorizeURL = "https://accounts.google.com/o/oauth2/v2/auth"
+	tokenURL     = "https://oauth2.googleapis.com/token"
+)
+
+// OAuthClient runs the authorization code flow with PKCE against Google using
+// the GOOGLE_CLIENT_ID / GOOGLE_CLIENT_SECRET credentials.
+type OAuthClient struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+}
+
+func NewOAuthClient(cfg *config.Config) *OAuthClient {
+	return &OAuthClient{
+		clientID:     cfg.GoogleClientID,
+		clientSecret: cfg.GoogleSecret,
+		redirectURL:  cfg.GoogleRedirectURL,
+		client:       &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Enabled reports whether all credentials needed for the flow are configured.
+func (c *OAuthClient) Enabled() bool {
+	return c.clientID != "" && c.clientSecret != "" && c.redirectURL != ""
+}
+
+func (c *OAuthClient) RedirectURL() string {
+	return c.redirectURL
+}
+
+// AuthCodeURL builds the URL the browser is sent to. codeChallenge is the
+// S256 PKCE challenge and nonce ends up in the ID token's nonce claim.
+func (c *OAuthClient) AuthCodeURL(state string, codeChallenge string, nonce string) string {
+	q := url.Values{}
+	q.Set("client_id", c.clientID)
+	q.Set("redirect_uri", c.redirectURL)
+	q.Set("response_type", "code")
+	q.Set("scope", "openid email profile")
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	q.Set("nonce", nonce)
+	q.Set("prompt", "select_account")
+	return authorizeURL + "?" + q.Encode()
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// Exchange redeems an authorization code together with the PKCE verifier
+// generated when the flow started.
+func (c *OAuthClient) Exchange(ctx context.Context, code string, codeVerifier string) (TokenResponse, error) {
+	form := url.Values{}
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+	form.Set("redirect_uri", c.redirectURL)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		var oauthErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(b, &oauthErr) == nil && oauthErr.Error != "" {
+			return TokenResponse{}, fmt.Errorf("google token exchange failed: %s %s", oauthErr.Error, oauthErr.ErrorDescription)
+		}
+		return TokenResponse{}, fmt.Errorf("google token exchange failed with status %d", resp.StatusCode)
+	}
+
+	var token TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return TokenResponse{}, err
+	}
+	if token.IDToken == "" {
+		return TokenResponse{}, errors.New("google did not return an id_token")
+	}
+	return token, nil
+}
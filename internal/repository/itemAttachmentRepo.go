@@ -0,0 +1,15 @@
+package repository
+
+import "github.com/l-fraga2811/back-sable/internal/models"
+
+// ItemAttachmentRepository stores the attachment rows only; file contents
+// live in a BlobStore.
+type ItemAttachmentRepository interface {
+	Create(att *models.ItemAttachment) error
+	ListByItem(itemID string) ([]models.ItemAttachment, error)
+	Get(itemID string, id string) (*models.ItemAttachment, error)
+	Delete(id string) error
+	// WithTx returns a repository working inside tx, the repository an
+	// ItemRepository.Transaction callback receives.
+	WithTx(tx ItemRepository) ItemAttachmentRepository
+}
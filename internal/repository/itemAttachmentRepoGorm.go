@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"gorm.io/gorm"
+)
+
+type itemAttachmentRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewItemAttachmentRepositoryGorm(db *gorm.DB) ItemAttachmentRepository {
+	return &itemAttachmentRepositoryGorm{db: db}
+}
+
+func (r *itemAttachmentRepositoryGorm) WithTx(tx ItemRepository) ItemAttachmentRepository {
+	return &itemAttachmentRepositoryGorm{db: gormTx(tx)}
+}
+
+func (r *itemAttachmentRepositoryGorm) Create(att *models.ItemAttachment) error {
+	return r.db.Create(att).Error
+}
+
+func (r *itemAttachmentRepositoryGorm) ListByItem(itemID string) ([]models.ItemAttachment, error) {
+	var atts []models.ItemAttachment
+	err := r.db.Where("item_id = ?", itemID).Order("created_at ASC").Find(&atts).Error
+	return atts, err
+}
+
+func (r *itemAttachmentRepositoryGorm) Get(itemID string, id string) (*models.ItemAttachment, error) {
+	var att models.ItemAttachment
+	if err := r.db.Where("item_id = ? AND id = ?", itemID, id).First(&att).Error; err != nil {
+		return nil, err
+	}
+	return &att, nil
+}
+
+func (r *itemAttachmentRepositoryGorm) Delete(id string) error {
+	return r.db.Delete(&models.ItemAttachment{}, "id = ?", id).Error
+}
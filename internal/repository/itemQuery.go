@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/l-fraga2811/back-sable/internal/models"
+)
+
+const (
+	DefaultItemPageSize = 20
+	MaxItemPageSize     = 100
+)
+
+var (
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSortField = errors.New("invalid sort field")
+	ErrEmptySearch      = errors.New("search query has no searchable terms")
+)
+
+// itemSortColumns whitelists the fields clients may sort by and maps them to
+// their database column.
+var itemSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"price":      "price",
+}
+
+// IsValidItemSortField reports whether field may be used in ItemListOptions.SortField.
+func IsValidItemSortField(field string) bool {
+	_, ok := itemSortColumns[field]
+	return ok
+}
+
+// ItemListOptions describes a page request over a user's items.
+// Zero values mean "no filter"; an empty SortField sorts by created_at.
+type ItemListOptions struct {
+	Limit         int
+	Cursor        string
+	Completed     *bool
+	MinPrice      *float64
+	MaxPrice      *float64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	SortField     string
+	SortAsc       bool
+	// Tags filters by tag name (case-insensitive). By default an item matches
+	// when it has any of the tags; with MatchAllTags it must have all of them.
+	Tags         []string
+	MatchAllTags bool
+}
+
+// ItemPage is a single page of items plus the cursor for the following page.
+// NextCursor is empty when there are no more results.
+type ItemPage struct {
+	Items      []models.Item
+	NextCursor string
+}
+
+// itemCursor is the decoded form of the opaque cursor handed to clients. It
+// carries the sort it was issued for so it cannot be replayed against another.
+type itemCursor struct {
+	SortField string `json:"s"`
+	SortAsc   bool   `json:"a"`
+	Value     string `json:"v"`
+	ID        string `json:"id"`
+}
+
+func encodeItemCursor(opts ItemListOptions, item models.Item) string {
+	cur := itemCursor{
+		SortField: opts.SortField,
+		SortAsc:   opts.SortAsc,
+		ID:        item.ID,
+	}
+
+	switch opts.SortField {
+	case "updated_at":
+		cur.Value = item.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case "title":
+		cur.Value = item.Title
+	case "price":
+		cur.Value = strconv.FormatFloat(item.Price, 'f', -1, 64)
+	default:
+		cur.Value = item.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeItemCursor parses a cursor and returns the typed sort value to compare
+// against along with the item ID used as a tie-breaker.
+func decodeItemCursor(opts ItemListOptions, raw string) (interface{}, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	var cur itemCursor
+	if err := json.Unmarshal(b, &cur); err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+	if cur.ID == "" || cur.SortField != opts.SortField || cur.SortAsc != opts.SortAsc {
+		return nil, "", ErrInvalidCursor
+	}
+
+	switch cur.SortField {
+	case "title":
+		return cur.Value, cur.ID, nil
+	case "price":
+		price, err := strconv.ParseFloat(cur.Value, 64)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		return price, cur.ID, nil
+	default:
+		ts, err := time.Parse(time.RFC3339Nano, cur.Value)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		return ts, cur.ID, nil
+	}
+}
+
+// ItemSearchResult is an item matched by full-text search, with its relevance
+// and highlighted snippets of the matching fields.
+type ItemSearchResult struct {
+	models.Item
+	Rank                 float64 `json:"rank"`
+	TitleHighlight       string  `json:"title_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
+}
+
+// buildPrefixTSQuery turns free text into a to_tsquery expression in which
+// every term must match as a prefix, e.g. "red sho" -> "red:* & sho:*".
+// Anything that is not a letter or digit is treated as a separator, so user
+// input can never inject tsquery operators.
+func buildPrefixTSQuery(text string) (string, error) {
+	terms := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) == 0 {
+		return "", ErrEmptySearch
+	}
+
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & "), nil
+}
@@ -1,12 +1,50 @@
 package repository
 
-import "github.com/l-fraga2811/back-sable/internal/models"
+import (
+    "errors"
+    "time"
+
+    "github.com/l-fraga2811/back-sable/internal/models"
+)
+
+// ErrVersionConflict is returned by Update and Delete when the item was
+// modified since it was read, i.e. its version no longer matches.
+var ErrVersionConflict = errors.New("item version conflict")
 
 type ItemRepository interface {
     Create(item *models.Item) error
     GetByID(id string) (*models.Item, error)
     GetAll(userID string) ([]models.Item, error)
+    List(userID string, opts ItemListOptions) (ItemPage, error)
+    // ListAll is List across every user's items, for administrators.
+    ListAll(opts ItemListOptions) (ItemPage, error)
+    Search(userID string, query string, limit int) ([]ItemSearchResult, error)
+    // Update writes item only if the stored version still equals item.Version,
+    // then bumps the version. It returns ErrVersionConflict otherwise.
     Update(item *models.Item) error
-    Delete(id string) error
+    // Delete soft-deletes item only if the stored version still equals
+    // item.Version. It returns ErrVersionConflict otherwise.
+    Delete(item *models.Item) error
     GetByUserID(userID string) ([]models.Item, error)
+    // ForEachBatch walks all of the user's items in creation order, handing
+    // them to fn batchSize at a time so they never sit in memory at once.
+    ForEachBatch(userID string, batchSize int, fn func(items []models.Item) error) error
+    CreateBatch(items []models.Item, batchSize int) error
+    AttachTags(itemID string, tags []models.Tag) error
+    DetachTag(itemID string, tagID string) error
+
+    // Trash: soft-deleted items can be listed, restored or purged for good.
+    ListTrash(userID string) ([]models.Item, error)
+    GetTrashedByID(id string) (*models.Item, error)
+    Restore(id string) error
+    Purge(id string) error
+    // PurgeDeletedBefore hard-deletes items trashed before cutoff with all of
+    // their rows, in one transaction, and returns how many items went and the
+    // storage keys of the attachments deleted with them.
+    PurgeDeletedBefore(cutoff time.Time) (int64, []string, error)
+
+    // Transaction runs fn with a repository bound to a single database
+    // transaction. Calling Transaction on that repository again opens a
+    // savepoint, so a failing inner call only rolls back its own work.
+    Transaction(fn func(tx ItemRepository) error) error
 }
\ No newline at end of file
@@ -2,6 +2,10 @@
 package repository
 
 import (
+    "fmt"
+    "strings"
+    "time"
+
     "github.com/l-fraga2811/back-sable/internal/models"
     "gorm.io/gorm"
 )
@@ -20,7 +24,7 @@ func (r *itemRepositoryGORM) Create(item *models.Item) error {
 
 func (r *itemRepositoryGORM) GetByID(id string) (*models.Item, error) {
     var item models.Item
-    err := r.db.Where("id = ?", id).First(&item).Error
+    err := r.db.Preload("Tags").Where("id = ?", id).First(&item).Error
     if err != nil {
         return nil, err
     }
@@ -29,20 +33,361 @@ func (r *itemRepositoryGORM) GetByID(id string) (*models.Item, error) {
 
 func (r *itemRepositoryGORM) GetAll(userID string) ([]models.Item, error) {
     var items []models.Item
-    err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error
+    err := r.db.Preload("Tags").Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error
     return items, err
 }
 
+func (r *itemRepositoryGORM) List(userID string, opts ItemListOptions) (ItemPage, error) {
+    return r.list(userID, opts)
+}
+
+func (r *itemRepositoryGORM) ListAll(opts ItemListOptions) (ItemPage, error) {
+    return r.list("", opts)
+}
+
+// list pages through userID's items, or everyone's when userID is empty.
+func (r *itemRepositoryGORM) list(userID string, opts ItemListOptions) (ItemPage, error) {
+    if opts.SortField == "" {
+        opts.SortField = "created_at"
+    }
+    column, ok := itemSortColumns[opts.SortField]
+    if !ok {
+        return ItemPage{}, ErrInvalidSortField
+    }
+    if opts.Limit <= 0 {
+        opts.Limit = DefaultItemPageSize
+    }
+    if opts.Limit > MaxItemPageSize {
+        opts.Limit = MaxItemPageSize
+    }
+
+    query := r.db.Preload("Tags")
+    if userID != "" {
+        query = query.Where("user_id = ?", userID)
+    }
+
+    if opts.Completed != nil {
+        query = query.Where("completed = ?", *opts.Completed)
+    }
+    if opts.MinPrice != nil {
+        query = query.Where("price >= ?", *opts.MinPrice)
+    }
+    if opts.MaxPrice != nil {
+        query = query.Where("price <= ?", *opts.MaxPrice)
+    }
+    if opts.CreatedAfter != nil {
+        query = query.Where("created_at >= ?", *opts.CreatedAfter)
+    }
+    if opts.CreatedBefore != nil {
+        query = query.Where("created_at < ?", *opts.CreatedBefore)
+    }
+
+    if len(opts.Tags) > 0 {
+        query = query.Where("id IN (?)", r.taggedItemIDs(userID, opts.Tags, opts.MatchAllTags))
+    }
+
+    direction, comparator := "DESC", "<"
+    if opts.SortAsc {
+        direction, comparator = "ASC", ">"
+    }
+
+    if opts.Cursor != "" {
+        value, lastID, err := decodeItemCursor(opts, opts.Cursor)
+        if err != nil {
+            return ItemPage{}, err
+        }
+        query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparator), value, lastID)
+    }
+
+    var items []models.Item
+    err := query.
+        Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+        Limit(opts.Limit + 1).
+        Find(&items).Error
+    if err != nil {
+        return ItemPage{}, err
+    }
+
+    page := ItemPage{Items: items}
+    if len(items) > opts.Limit {
+        page.Items = items[:opts.Limit]
+        page.NextCursor = encodeItemCursor(opts, page.Items[opts.Limit-1])
+    }
+    return page, nil
+}
+
+// searchHeadlineOptions configures ts_headline snippets in search results.
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=5, MaxFragments=2"
+
+// htmlEscapeColumn returns a SQL expression that HTML-escapes column, so the
+// only markup in a ts_headline snippet is the <mark> pair it inserts itself.
+func htmlEscapeColumn(column string) string {
+    return fmt.Sprintf(`replace(replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`, column)
+}
+
+// Search ranks the user's items against query using the search_vector column
+// created by cmd/migrate. Terms are matched by prefix.
+func (r *itemRepositoryGORM) Search(userID string, query string, limit int) ([]ItemSearchResult, error) {
+    tsQuery, err := buildPrefixTSQuery(query)
+    if err != nil {
+        return nil, err
+    }
+    if limit <= 0 {
+        limit = DefaultItemPageSize
+    }
+    if limit > MaxItemPageSize {
+        limit = MaxItemPageSize
+    }
+
+    var results []ItemSearchResult
+    err = r.db.Table("items, to_tsquery('simple', ?) AS query", tsQuery).
+        Select(fmt.Sprintf(`items.*,
+            ts_rank(items.search_vector, query) AS rank,
+            ts_headline('simple', %s, query, ?) AS title_highlight,
+            ts_headline('simple', %s, query, ?) AS description_highlight`,
+            htmlEscapeColumn("items.title"), htmlEscapeColumn("coalesce(items.description, '')")),
+            searchHeadlineOptions, searchHeadlineOptions).
+        Where("items.user_id = ? AND items.deleted_at IS NULL AND items.search_vector @@ query", userID).
+        Order("rank DESC, items.created_at DESC").
+        Limit(limit).
+        Scan(&results).Error
+    if err != nil || len(results) == 0 {
+        return results, err
+    }
+
+    // Scan into the wrapper type skips associations, so load tags separately.
+    ids := make([]string, len(results))
+    for i := range results {
+        ids[i] = results[i].ID
+    }
+    var tagged []models.Item
+    if err := r.db.Preload("Tags").Select("id").Where("id IN ?", ids).Find(&tagged).Error; err != nil {
+        return nil, err
+    }
+    tags := make(map[string][]models.Tag, len(tagged))
+    for _, item := range tagged {
+        tags[item.ID] = item.Tags
+    }
+    for i := range results {
+        results[i].Tags = tags[results[i].ID]
+        if results[i].Tags == nil {
+            results[i].Tags = []models.Tag{}
+        }
+    }
+    return results, nil
+}
+
+// taggedItemIDs builds a subquery selecting the IDs of items carrying any (or,
+// with matchAll, every one) of the named tags. An empty userID matches tags of
+// any user.
+func (r *itemRepositoryGORM) taggedItemIDs(userID string, names []string, matchAll bool) *gorm.DB {
+    seen := make(map[string]struct{}, len(names))
+    lowered := make([]string, 0, len(names))
+    for _, name := range names {
+        name = strings.ToLower(strings.TrimSpace(name))
+        if _, ok := seen[name]; ok || name == "" {
+            continue
+        }
+        seen[name] = struct{}{}
+        lowered = append(lowered, name)
+    }
+
+    sub := r.db.Table("item_tags").
+        Select("item_tags.item_id").
+        Joins("JOIN tags ON tags.id = item_tags.tag_id").
+        Where("lower(tags.name) IN ?", lowered)
+    if userID != "" {
+        sub = sub.Where("tags.user_id = ?", userID)
+    }
+    if matchAll {
+        sub = sub.Group("item_tags.item_id").Having("COUNT(DISTINCT lower(tags.name)) = ?", len(lowered))
+    }
+    return sub
+}
+
+func (r *itemRepositoryGORM) ForEachBatch(userID string, batchSize int, fn func(items []models.Item) error) error {
+    opts := ItemListOptions{Limit: batchSize, SortField: "created_at", SortAsc: true}
+    for {
+        page, err := r.List(userID, opts)
+        if err != nil {
+            return err
+        }
+        if len(page.Items) > 0 {
+            if err := fn(page.Items); err != nil {
+                return err
+            }
+        }
+        if page.NextCursor == "" {
+            return nil
+        }
+        opts.Cursor = page.NextCursor
+    }
+}
+
+func (r *itemRepositoryGORM) CreateBatch(items []models.Item, batchSize int) error {
+    if len(items) == 0 {
+        return nil
+    }
+    return r.db.CreateInBatches(&items, batchSize).Error
+}
+
 func (r *itemRepositoryGORM) Update(item *models.Item) error {
-    return r.db.Save(item).Error
+    now := time.Now()
+    result := r.db.Model(&models.Item{}).
+        Where("id = ? AND version = ?", item.ID, item.Version).
+        Updates(map[string]interface{}{
+            "title":       item.Title,
+            "description": item.Description,
+            "price":       item.Price,
+            "completed":   item.Completed,
+            "version":     gorm.Expr("version + 1"),
+            "updated_at":  now,
+        })
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return ErrVersionConflict
+    }
+
+    item.Version++
+    item.UpdatedAt = now
+    return nil
 }
 
-func (r *itemRepositoryGORM) Delete(id string) error {
-    return r.db.Delete(&models.Item{}, "id = ?", id).Error
+func (r *itemRepositoryGORM) Delete(item *models.Item) error {
+    result := r.db.Where("id = ? AND version = ?", item.ID, item.Version).Delete(&models.Item{})
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return ErrVersionConflict
+    }
+    return nil
 }
 
 func (r *itemRepositoryGORM) GetByUserID(userID string) ([]models.Item, error) {
     var items []models.Item
-    err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error
+    err := r.db.Preload("Tags").Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error
     return items, err
 }
+
+func (r *itemRepositoryGORM) ListTrash(userID string) ([]models.Item, error) {
+    var items []models.Item
+    err := r.db.Unscoped().Preload("Tags").
+        Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+        Order("deleted_at DESC").
+        Find(&items).Error
+    return items, err
+}
+
+func (r *itemRepositoryGORM) GetTrashedByID(id string) (*models.Item, error) {
+    var item models.Item
+    err := r.db.Unscoped().Preload("Tags").Where("id = ? AND deleted_at IS NOT NULL", id).First(&item).Error
+    if err != nil {
+        return nil, err
+    }
+    return &item, nil
+}
+
+func (r *itemRepositoryGORM) Restore(id string) error {
+    result := r.db.Unscoped().Model(&models.Item{}).
+        Where("id = ? AND deleted_at IS NOT NULL", id).
+        Updates(map[string]interface{}{
+            "deleted_at": nil,
+            "version":    gorm.Expr("version + 1"),
+            "updated_at": time.Now(),
+        })
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
+}
+
+func (r *itemRepositoryGORM) Purge(id string) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Exec("DELETE FROM item_tags WHERE item_id = ?", id).Error; err != nil {
+            return err
+        }
+        if err := tx.Exec("DELETE FROM item_revisions WHERE item_id = ?", id).Error; err != nil {
+            return err
+        }
+        if err := tx.Exec("DELETE FROM item_shares WHERE item_id = ?", id).Error; err != nil {
+            return err
+        }
+        if err := tx.Exec("DELETE FROM item_attachments WHERE item_id = ?", id).Error; err != nil {
+            return err
+        }
+        return tx.Unscoped().Delete(&models.Item{}, "id = ?", id).Error
+    })
+}
+
+func (r *itemRepositoryGORM) PurgeDeletedBefore(cutoff time.Time) (int64, []string, error) {
+    var (
+        purged      int64
+        storageKeys []string
+    )
+    err := r.db.Transaction(func(tx *gorm.DB) error {
+        // Lock the expired items first: a concurrent Restore then waits for
+        // this transaction, so every statement below sees the same items.
+        err := tx.Exec(`SELECT id FROM items WHERE deleted_at IS NOT NULL AND deleted_at < ? FOR UPDATE`, cutoff).Error
+        if err != nil {
+            return err
+        }
+        err = tx.Exec(`DELETE FROM item_tags WHERE item_id IN (
+            SELECT id FROM items WHERE deleted_at IS NOT NULL AND deleted_at < ?)`, cutoff).Error
+        if err != nil {
+            return err
+        }
+        err = tx.Exec(`DELETE FROM item_revisions WHERE item_id IN (
+            SELECT id FROM items WHERE deleted_at IS NOT NULL AND deleted_at < ?)`, cutoff).Error
+        if err != nil {
+            return err
+        }
+        err = tx.Exec(`DELETE FROM item_shares WHERE item_id IN (
+            SELECT id FROM items WHERE deleted_at IS NOT NULL AND deleted_at < ?)`, cutoff).Error
+        if err != nil {
+            return err
+        }
+        err = tx.Raw(`DELETE FROM item_attachments WHERE item_id IN (
+            SELECT id FROM items WHERE deleted_at IS NOT NULL AND deleted_at < ?)
+            RETURNING storage_key`, cutoff).Scan(&storageKeys).Error
+        if err != nil {
+            return err
+        }
+        result := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.Item{})
+        purged = result.RowsAffected
+        return result.Error
+    })
+    if err != nil {
+        return 0, nil, err
+    }
+    return purged, storageKeys, nil
+}
+
+func (r *itemRepositoryGORM) Transaction(fn func(tx ItemRepository) error) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        return fn(&itemRepositoryGORM{db: tx})
+    })
+}
+
+// gormTx returns the database handle a repository from Transaction is bound
+// to, so the other item repositories' WithTx can join that transaction.
+func gormTx(tx ItemRepository) *gorm.DB {
+    r, ok := tx.(*itemRepositoryGORM)
+    if !ok {
+        panic("repository: WithTx needs a repository handed out by the GORM ItemRepository")
+    }
+    return r.db
+}
+
+func (r *itemRepositoryGORM) AttachTags(itemID string, tags []models.Tag) error {
+    return r.db.Model(&models.Item{ID: itemID}).Association("Tags").Append(tags)
+}
+
+func (r *itemRepositoryGORM) DetachTag(itemID string, tagID string) error {
+    return r.db.Model(&models.Item{ID: itemID}).Association("Tags").Delete(&models.Tag{ID: tagID})
+}
@@ -0,0 +1,16 @@
+package repository
+
+import "github.com/l-fraga2811/back-sable/internal/models"
+
+type ItemRevisionRepository interface {
+	// Create assigns rev the item's next revision number and stores it.
+	Create(rev *models.ItemRevision) error
+	// CreateNumbered inserts revisions whose Revision number is already set,
+	// e.g. the first revision of freshly imported items.
+	CreateNumbered(revs []models.ItemRevision) error
+	ListByItem(itemID string) ([]models.ItemRevision, error)
+	Get(itemID string, revision int) (*models.ItemRevision, error)
+	// WithTx returns a repository working inside tx, the repository an
+	// ItemRepository.Transaction callback receives.
+	WithTx(tx ItemRepository) ItemRevisionRepository
+}
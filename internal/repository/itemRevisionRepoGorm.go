@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"gorm.io/gorm"
+)
+
+type itemRevisionRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewItemRevisionRepositoryGorm(db *gorm.DB) ItemRevisionRepository {
+	return &itemRevisionRepositoryGorm{db: db}
+}
+
+func (r *itemRevisionRepositoryGorm) WithTx(tx ItemRepository) ItemRevisionRepository {
+	return &itemRevisionRepositoryGorm{db: gormTx(tx)}
+}
+
+func (r *itemRevisionRepositoryGorm) Create(rev *models.ItemRevision) error {
+	var last int
+	err := r.db.Model(&models.ItemRevision{}).
+		Where("item_id = ?", rev.ItemID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+
+	rev.Revision = last + 1
+	return r.db.Create(rev).Error
+}
+
+func (r *itemRevisionRepositoryGorm) CreateNumbered(revs []models.ItemRevision) error {
+	if len(revs) == 0 {
+		return nil
+	}
+	return r.db.Create(&revs).Error
+}
+
+func (r *itemRevisionRepositoryGorm) ListByItem(itemID string) ([]models.ItemRevision, error) {
+	var revisions []models.ItemRevision
+	err := r.db.Where("item_id = ?", itemID).Order("revision DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *itemRevisionRepositoryGorm) Get(itemID string, revision int) (*models.ItemRevision, error) {
+	var rev models.ItemRevision
+	err := r.db.Where("item_id = ? AND revision = ?", itemID, revision).First(&rev).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import "github.com/l-fraga2811/back-sable/internal/models"
+
+// SharedItem is an item another user shared with the caller, along with the
+// role they were granted.
+type SharedItem struct {
+	models.Item
+	Role string `json:"role"`
+}
+
+type ItemShareRepository interface {
+	// Upsert replaces the role of an existing grant.
+	Upsert(share *models.ItemShare) error
+	// Role returns the role granteeID holds on itemID, or "" if none.
+	Role(itemID string, granteeID string) (string, error)
+	ListByItem(itemID string) ([]models.ItemShare, error)
+	// Delete returns gorm.ErrRecordNotFound when there was no such grant.
+	Delete(itemID string, granteeID string) error
+	ListSharedWith(granteeID string) ([]SharedItem, error)
+	// WithTx returns a repository working inside tx, the repository an
+	// ItemRepository.Transaction callback receives.
+	WithTx(tx ItemRepository) ItemShareRepository
+}
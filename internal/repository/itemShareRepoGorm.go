@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type itemShareRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewItemShareRepositoryGorm(db *gorm.DB) ItemShareRepository {
+	return &itemShareRepositoryGorm{db: db}
+}
+
+func (r *itemShareRepositoryGorm) WithTx(tx ItemRepository) ItemShareRepository {
+	return &itemShareRepositoryGorm{db: gormTx(tx)}
+}
+
+func (r *itemShareRepositoryGorm) Upsert(share *models.ItemShare) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "item_id"}, {Name: "grantee_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "granted_by", "updated_at"}),
+	}).Create(share).Error
+}
+
+func (r *itemShareRepositoryGorm) Role(itemID string, granteeID string) (string, error) {
+	var shares []models.ItemShare
+	err := r.db.Where("item_id = ? AND grantee_id = ?", itemID, granteeID).Limit(1).Find(&shares).Error
+	if err != nil || len(shares) == 0 {
+		return "", err
+	}
+	return shares[0].Role, nil
+}
+
+func (r *itemShareRepositoryGorm) ListByItem(itemID string) ([]models.ItemShare, error) {
+	var shares []models.ItemShare
+	err := r.db.Where("item_id = ?", itemID).Order("created_at ASC").Find(&shares).Error
+	return shares, err
+}
+
+func (r *itemShareRepositoryGorm) Delete(itemID string, granteeID string) error {
+	result := r.db.Where("item_id = ? AND grantee_id = ?", itemID, granteeID).Delete(&models.ItemShare{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *itemShareRepositoryGorm) ListSharedWith(granteeID string) ([]SharedItem, error) {
+	var shares []models.ItemShare
+	if err := r.db.Where("grantee_id = ?", granteeID).Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return []SharedItem{}, nil
+	}
+
+	roles := make(map[string]string, len(shares))
+	itemIDs := make([]string, 0, len(shares))
+	for _, share := range shares {
+		roles[share.ItemID] = share.Role
+		itemIDs = append(itemIDs, share.ItemID)
+	}
+
+	var items []models.Item
+	err := r.db.Where("id IN ?", itemIDs).Order("created_at DESC").Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags belong to the owner and are not shared along with the item.
+	shared := make([]SharedItem, 0, len(items))
+	for _, item := range items {
+		item.Tags = []models.Tag{}
+		shared = append(shared, SharedItem{Item: item, Role: roles[item.ID]})
+	}
+	return shared, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// maxOAuthStates bounds how many pending states the memory store holds.
+	maxOAuthStates = 10000
+	// oauthStateSweepInterval is how often Save drops expired states.
+	oauthStateSweepInterval = time.Minute
+)
+
+// ErrTooManyOAuthStates is returned by Save when the store is full of
+// unexpired states.
+var ErrTooManyOAuthStates = errors.New("too many pending oauth states")
+
+// OAuthState is what the server remembers between redirecting a browser to
+// the identity provider and handling its callback.
+type OAuthState struct {
+	CodeVerifier string
+	Nonce        string
+	// BindingHash is the SHA-256 of the value stored in the browser's cookie,
+	// tying the state to the browser that started the flow.
+	BindingHash string
+	ExpiresAt   time.Time
+}
+
+// OAuthStateStore keeps pending OAuth states. Consume must remove the state
+// it returns so that a callback can never be replayed.
+type OAuthStateStore interface {
+	Save(state string, entry OAuthState) error
+	Consume(state string) (OAuthState, bool, error)
+}
+
+type memoryOAuthStateStore struct {
+	mu        sync.Mutex
+	states    map[string]OAuthState
+	nextSweep time.Time
+}
+
+func NewMemoryOAuthStateStore() OAuthStateStore {
+	return &memoryOAuthStateStore{states: map[string]OAuthState{}}
+}
+
+func (s *memoryOAuthStateStore) Save(state string, entry OAuthState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Sweep at most once per interval so a burst of Saves does not scan the
+	// whole map every time.
+	now := time.Now()
+	if !now.Before(s.nextSweep) {
+		for key, existing := range s.states {
+			if !now.Before(existing.ExpiresAt) {
+				delete(s.states, key)
+			}
+		}
+		s.nextSweep = now.Add(oauthStateSweepInterval)
+	}
+	if len(s.states) >= maxOAuthStates {
+		return ErrTooManyOAuthStates
+	}
+	s.states[state] = entry
+	return nil
+}
+
+func (s *memoryOAuthStateStore) Consume(state string) (OAuthState, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.states[state]
+	if !ok {
+		return OAuthState{}, false, nil
+	}
+	delete(s.states, state)
+
+	if !time.Now().Before(entry.ExpiresAt) {
+		return OAuthState{}, false, nil
+	}
+	return entry, true, nil
+}
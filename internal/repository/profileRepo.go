@@ -1,7 +1,25 @@
 package repository
 
-import "github.com/l-fraga2811/back-sable/internal/models"
+import (
+	"errors"
+
+	"github.com/l-fraga2811/back-sable/internal/models"
+)
+
+// ErrUsernameTaken is returned by Save when another profile already uses the
+// username, compared case-insensitively.
+var ErrUsernameTaken = errors.New("username already taken")
 
 type ProfileRepository interface {
+	// GetByID returns nil, nil when the user has no profile row.
 	GetByID(id string) (*models.Profile, error)
+	// UsernameTaken reports whether a profile other than excludeID uses
+	// username, compared case-insensitively.
+	UsernameTaken(username string, excludeID string) (bool, error)
+	// Save inserts or updates profile.
+	Save(profile *models.Profile) error
+	// Provision inserts profile unless a row with its ID already exists, and
+	// reports whether it did. If the username is taken the profile is
+	// created without one. It is safe to call repeatedly.
+	Provision(profile *models.Profile) (bool, error)
 }
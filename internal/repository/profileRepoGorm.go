@@ -1,8 +1,12 @@
 package repository
 
 import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/l-fraga2811/back-sable/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type profileRepositoryGorm struct {
@@ -15,8 +19,60 @@ func NewProfileRepositoryGorm(db *gorm.DB) ProfileRepository {
 
 func (r *profileRepositoryGorm) GetByID(id string) (*models.Profile, error) {
 	var profile models.Profile
-	if err := r.db.Where("id = ?", id).First(&profile).Error; err != nil {
+	err := r.db.Where("id = ?", id).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &profile, nil
 }
+
+func (r *profileRepositoryGorm) UsernameTaken(username string, excludeID string) (bool, error) {
+	query := r.db.Model(&models.Profile{}).Where("lower(username) = lower(?)", username)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *profileRepositoryGorm) Save(profile *models.Profile) error {
+	err := r.db.Save(profile).Error
+	// The unique index on lower(username) catches races UsernameTaken misses.
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+func (r *profileRepositoryGorm) Provision(profile *models.Profile) (bool, error) {
+	created, err := r.insertIfMissing(profile)
+	if errors.Is(err, ErrUsernameTaken) {
+		profile.Username = ""
+		created, err = r.insertIfMissing(profile)
+	}
+	return created, err
+}
+
+func (r *profileRepositoryGorm) insertIfMissing(profile *models.Profile) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoNothing: true,
+	}).Create(profile)
+
+	var pgErr *pgconn.PgError
+	if errors.As(result.Error, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return false, ErrUsernameTaken
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
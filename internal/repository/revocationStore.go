@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenRevocationStore remembers access tokens that were signed out before
+// they expired. Entries only need to live until the tokens they cover would
+// have expired anyway, so stores may drop them after expiresAt.
+//
+// The in-memory store below is process-local; deployments running several
+// instances should plug in a shared implementation (e.g. Redis or Postgres).
+type TokenRevocationStore interface {
+	// RevokeSession rejects every token carrying this session ID (or jti).
+	RevokeSession(sessionID string, expiresAt time.Time) error
+	IsSessionRevoked(sessionID string) (bool, error)
+	// RevokeUser rejects every token of userID issued before cutoff.
+	RevokeUser(userID string, cutoff time.Time, expiresAt time.Time) error
+	// UserRevokedBefore returns the cutoff set by RevokeUser, if any.
+	UserRevokedBefore(userID string) (time.Time, bool, error)
+}
+
+type userRevocation struct {
+	cutoff    time.Time
+	expiresAt time.Time
+}
+
+type memoryRevocationStore struct {
+	mu       sync.RWMutex
+	sessions map[string]time.Time
+	users    map[string]userRevocation
+}
+
+func NewMemoryRevocationStore() TokenRevocationStore {
+	return &memoryRevocationStore{
+		sessions: map[string]time.Time{},
+		users:    map[string]userRevocation{},
+	}
+}
+
+func (s *memoryRevocationStore) RevokeSession(sessionID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+	s.sessions[sessionID] = expiresAt
+	return nil
+}
+
+func (s *memoryRevocationStore) IsSessionRevoked(sessionID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expiresAt, ok := s.sessions[sessionID]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *memoryRevocationStore) RevokeUser(userID string, cutoff time.Time, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+	if existing, ok := s.users[userID]; ok && existing.cutoff.After(cutoff) {
+		cutoff = existing.cutoff
+	}
+	s.users[userID] = userRevocation{cutoff: cutoff, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryRevocationStore) UserRevokedBefore(userID string) (time.Time, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.users[userID]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return time.Time{}, false, nil
+	}
+	return entry.cutoff, true, nil
+}
+
+// pruneLocked drops entries whose tokens have expired. Callers hold s.mu.
+func (s *memoryRevocationStore) pruneLocked(now time.Time) {
+	for id, expiresAt := range s.sessions {
+		if !now.Before(expiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+	for id, entry := range s.users {
+		if !now.Before(entry.expiresAt) {
+			delete(s.users, id)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+var ErrServiceRoleKeyMissing = errors.New("SUPABASE_SERVICE_ROLE_KEY not defined")
+
+// ListUsers returns one page (1-based) of the project's users. It uses the
+// service role key and must only be called from trusted tooling.
+func (c *Client) ListUsers(ctx context.Context, page int, perPage int) ([]User, error) {
+	if c.serviceRoleKey == "" {
+		return nil, ErrServiceRoleKeyMissing
+	}
+
+	// Supabase Auth endpoint: /auth/v1/admin/users
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(perPage))
+
+	var response struct {
+		Users []User `json:"users"`
+	}
+	headers := map[string]string{"apikey": c.serviceRoleKey}
+	err := c.doJSON(ctx, http.MethodGet, "/auth/v1/admin/users", c.serviceRoleKey, q, nil, &response, headers)
+	if err != nil {
+		return nil, err
+	}
+	return response.Users, nil
+}
@@ -0,0 +1,62 @@
+package supabase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// StorageBlobStore keeps blobs in a private Supabase Storage bucket. It acts
+// with the service role key, so access control is up to the caller.
+type StorageBlobStore struct {
+	client *Client
+	bucket string
+}
+
+// NewStorageBlobStore fails with ErrServiceRoleKeyMissing when the client
+// has no service role key, so a misconfigured API stops at startup instead of
+// on the first upload.
+func NewStorageBlobStore(client *Client, bucket string) (*StorageBlobStore, error) {
+	if client.serviceRoleKey == "" {
+		return nil, ErrServiceRoleKeyMissing
+	}
+	return &StorageBlobStore{client: client, bucket: bucket}, nil
+}
+
+func (s *StorageBlobStore) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	return s.client.UploadObject(ctx, s.client.serviceRoleKey, s.bucket, key, contentType, data, false)
+}
+
+func (s *StorageBlobStore) Delete(ctx context.Context, key string) error {
+	return s.client.DeleteObject(ctx, s.client.serviceRoleKey, s.bucket, key)
+}
+
+func (s *StorageBlobStore) SignedURL(ctx context.Context, key string, filename string, ttl time.Duration) (string, error) {
+	return s.client.CreateSignedObjectURL(ctx, s.client.serviceRoleKey, s.bucket, key, int(ttl.Seconds()), filename)
+}
+
+// DeleteObject removes path from bucket. Deleting a missing object is not an
+// error.
+func (c *Client) DeleteObject(ctx context.Context, accessToken string, bucket string, path string) error {
+	// Supabase Storage endpoint: DELETE /storage/v1/object/{bucket}/{path}
+	resp, err := c.do(ctx, http.MethodDelete, objectPath("/storage/v1/object/", bucket, path), accessToken, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		apiErr := newAPIError(resp.StatusCode, b)
+		// Storage reports missing objects as 400 with a not_found code.
+		if apiErr.Code == "not_found" {
+			return nil
+		}
+		return apiErr
+	}
+	return nil
+}
@@ -16,16 +16,18 @@ import (
 )
 
 type Client struct {
-	projectURL string
-	anonKey    string
-	client     *http.Client
+	projectURL     string
+	anonKey        string
+	serviceRoleKey string
+	client         *http.Client
 }
 
 func NewClient(cfg *config.Config) *Client {
 	return &Client{
-		projectURL: strings.TrimRight(cfg.SupabaseURL, "/"),
-		anonKey:    cfg.SupabaseKey,
-		client:     &http.Client{Timeout: 15 * time.Second},
+		projectURL:     strings.TrimRight(cfg.SupabaseURL, "/"),
+		anonKey:        cfg.SupabaseKey,
+		serviceRoleKey: cfg.SupabaseServiceRoleKey,
+		client:         &http.Client{Timeout: 15 * time.Second},
 	}
 }
 
@@ -72,6 +74,16 @@ type User struct {
 	UserMetadata map[string]interface{} `json:"user_metadata"`
 }
 
+// UserAttributes are the fields of the signed-in user that UpdateUser can
+// change. Empty fields are left untouched.
+type UserAttributes struct {
+	Email    string                 `json:"email,omitempty"`
+	Phone    string                 `json:"phone,omitempty"`
+	Password string                 `json:"password,omitempty"`
+	Nonce    string                 `json:"nonce,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
 type SignInCredentials struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -106,6 +118,131 @@ func (c *Client) SignUp(ctx context.Context, creds SignUpCredentials) (AuthRespo
 	return response, nil
 }
 
+// RefreshSession exchanges a refresh token for a new session. Supabase rotates
+// refresh tokens, so the one in the response replaces the one sent.
+func (c *Client) RefreshSession(ctx context.Context, refreshToken string) (AuthResponse, error) {
+	var response AuthResponse
+	// Supabase Auth endpoint: /auth/v1/token?grant_type=refresh_token
+	q := url.Values{}
+	q.Set("grant_type", "refresh_token")
+
+	payload := map[string]string{"refresh_token": refreshToken}
+	err := c.doJSON(ctx, http.MethodPost, "/auth/v1/token", "", q, payload, &response, nil)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	return response, nil
+}
+
+const (
+	SignOutScopeGlobal = "global"
+	SignOutScopeLocal  = "local"
+)
+
+// SignOut ends the session behind accessToken. With SignOutScopeGlobal every
+// session of the user is ended; refresh tokens stop working immediately but
+// already-issued access tokens stay valid until they expire.
+func (c *Client) SignOut(ctx context.Context, accessToken string, scope string) error {
+	// Supabase Auth endpoint: /auth/v1/logout?scope=global|local
+	q := url.Values{}
+	q.Set("scope", scope)
+
+	return c.doJSON(ctx, http.MethodPost, "/auth/v1/logout", accessToken, q, nil, nil, nil)
+}
+
+// SendPasswordRecovery asks Supabase to email a password reset link to email.
+// redirectTo, if set, is where the link sends the user afterwards and must be
+// allowed in the project's redirect URL settings.
+func (c *Client) SendPasswordRecovery(ctx context.Context, email string, redirectTo string) error {
+	// Supabase Auth endpoint: /auth/v1/recover
+	var q url.Values
+	if redirectTo != "" {
+		q = url.Values{}
+		q.Set("redirect_to", redirectTo)
+	}
+
+	payload := map[string]string{"email": email}
+	return c.doJSON(ctx, http.MethodPost, "/auth/v1/recover", "", q, payload, nil, nil)
+}
+
+// UpdateUser changes attributes of the user that owns accessToken.
+func (c *Client) UpdateUser(ctx context.Context, accessToken string, attrs UserAttributes) (User, error) {
+	var user User
+	// Supabase Auth endpoint: /auth/v1/user
+	err := c.doJSON(ctx, http.MethodPut, "/auth/v1/user", accessToken, nil, attrs, &user, nil)
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// OTPRequest asks Supabase to send a one-time code or magic link. Set either
+// Email or Phone; Channel ("sms" or "whatsapp") only applies to phones.
+type OTPRequest struct {
+	Email      string                 `json:"email,omitempty"`
+	Phone      string                 `json:"phone,omitempty"`
+	Channel    string                 `json:"channel,omitempty"`
+	CreateUser bool                   `json:"create_user"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// VerifyOTPRequest exchanges a code for a session. Token is the code sent by
+// email or SMS; TokenHash is the hash carried by magic links, in which case
+// Email and Phone are not needed.
+type VerifyOTPRequest struct {
+	Type      string `json:"type"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+	Token     string `json:"token,omitempty"`
+	TokenHash string `json:"token_hash,omitempty"`
+}
+
+// SendOTP triggers a one-time code (or magic link, depending on the project's
+// email template) for passwordless sign-in.
+func (c *Client) SendOTP(ctx context.Context, req OTPRequest, redirectTo string) error {
+	// Supabase Auth endpoint: /auth/v1/otp
+	var q url.Values
+	if redirectTo != "" {
+		q = url.Values{}
+		q.Set("redirect_to", redirectTo)
+	}
+
+	return c.doJSON(ctx, http.MethodPost, "/auth/v1/otp", "", q, req, nil, nil)
+}
+
+func (c *Client) VerifyOTP(ctx context.Context, req VerifyOTPRequest) (AuthResponse, error) {
+	var response AuthResponse
+	// Supabase Auth endpoint: /auth/v1/verify
+	err := c.doJSON(ctx, http.MethodPost, "/auth/v1/verify", "", nil, req, &response, nil)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	return response, nil
+}
+
+// IDTokenCredentials signs in with an OpenID Connect ID token obtained from
+// a provider configured in Supabase. Nonce is the raw nonce whose SHA-256 was
+// sent to the provider.
+type IDTokenCredentials struct {
+	Provider    string `json:"provider"`
+	IDToken     string `json:"id_token"`
+	AccessToken string `json:"access_token,omitempty"`
+	Nonce       string `json:"nonce,omitempty"`
+}
+
+func (c *Client) SignInWithIDToken(ctx context.Context, creds IDTokenCredentials) (AuthResponse, error) {
+	var response AuthResponse
+	// Supabase Auth endpoint: /auth/v1/token?grant_type=id_token
+	q := url.Values{}
+	q.Set("grant_type", "id_token")
+
+	err := c.doJSON(ctx, http.MethodPost, "/auth/v1/token", "", q, creds, &response, nil)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	return response, nil
+}
+
 func (c *Client) ListItems(ctx context.Context, accessToken string) ([]ItemRow, error) {
 	q := url.Values{}
 	q.Set("select", "*")
@@ -195,12 +332,11 @@ func (c *Client) doJSON(ctx context.Context, method string, path string, accessT
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		if len(b) == 0 {
-			return errors.New("supabase request failed")
+		if len(b) > 0 {
+			// Log the error for debugging
+			fmt.Printf("Supabase Error [Status %d]: %s\n", resp.StatusCode, string(b))
 		}
-		// Log the error for debugging
-		fmt.Printf("Supabase Error [Status %d]: %s\n", resp.StatusCode, string(b))
-		return errors.New(string(b))
+		return newAPIError(resp.StatusCode, b)
 	}
 
 	if out == nil {
@@ -0,0 +1,53 @@
+package supabase
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned for any non-2xx response from Supabase. Code holds the
+// machine-readable error code when Supabase sends one (e.g. "weak_password",
+// "invalid_grant" or a PostgREST "PGRST..." code).
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// newAPIError extracts a code and message from the error bodies returned by
+// GoTrue (error_code/msg, error/error_description) and PostgREST
+// (code/message), falling back to the raw body.
+func newAPIError(status int, body []byte) *APIError {
+	apiErr := &APIError{Status: status}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		apiErr.Code = firstString(parsed, "error_code", "code", "error")
+		apiErr.Message = firstString(parsed, "msg", "message", "error_description", "error")
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = "supabase request failed"
+	}
+	return apiErr
+}
+
+func firstString(values map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := values[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
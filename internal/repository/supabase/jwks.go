@@ -1,12 +1,21 @@
 package supabase
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"math/big"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -22,61 +31,178 @@ type jwkKey struct {
 	Alg string `json:"alg"`
 	N   string `json:"n"`
 	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
 }
 
+// PublicKey is a verification key from the JWKS together with the JWS
+// algorithms tokens signed with it may use.
+type PublicKey struct {
+	Key        crypto.PublicKey
+	Algorithms []string
+}
+
+// keyAlgorithms lists the algorithms each key type can verify, keyed by kty
+// and, for EC and OKP keys, the curve. A JWK's alg, when present, narrows this
+// to a single algorithm.
+var keyAlgorithms = map[string][]string{
+	"RSA":         {"RS256", "RS384", "RS512"},
+	"EC/P-256":    {"ES256"},
+	"EC/P-384":    {"ES384"},
+	"OKP/Ed25519": {"EdDSA"},
+}
+
+// AsymmetricAlgorithms are all algorithms JwksCache keys can verify.
+var AsymmetricAlgorithms = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}
+
+// JWKS cache tuning. Keys are refreshed in the background refreshAhead
+// before they expire; if fetching fails they keep being served for up to
+// staleGrace after expiry. Lookups of unknown kids refetch at most once per
+// unknownKidInterval so garbage tokens cannot flood the JWKS endpoint.
+const (
+	defaultJwksTTL     = 10 * time.Minute
+	minJwksTTL         = time.Minute
+	maxJwksTTL         = 24 * time.Hour
+	refreshAhead       = time.Minute
+	refreshRetry       = 30 * time.Second
+	staleGrace         = time.Hour
+	unknownKidInterval = 30 * time.Second
+)
+
 type JwksCache struct {
 	jwksURL string
 	client  *http.Client
 
-	mu        sync.RWMutex
-	publicKey map[string]*rsa.PublicKey
-	expiresAt time.Time
-	ttl       time.Duration
+	mu          sync.RWMutex
+	publicKey   map[string]PublicKey
+	expiresAt   time.Time
+	lastAttempt time.Time
+
+	// inflight is the fetch currently running, shared by every caller that
+	// needs a refresh at the same time.
+	fetchMu  sync.Mutex
+	inflight *jwksFetch
+}
+
+type jwksFetch struct {
+	done chan struct{}
+	err  error
 }
 
 func NewJwksCache(jwksURL string) *JwksCache {
 	return &JwksCache{
 		jwksURL:   jwksURL,
 		client:    &http.Client{Timeout: 10 * time.Second},
-		publicKey: map[string]*rsa.PublicKey{},
-		ttl:       10 * time.Minute,
+		publicKey: map[string]PublicKey{},
+	}
+}
+
+// Start refreshes the keys in the background shortly before they expire, so
+// requests do not wait on the JWKS endpoint. It runs until ctx is cancelled.
+func (c *JwksCache) Start(ctx context.Context) {
+	if c.jwksURL == "" {
+		return
 	}
+
+	go func() {
+		for {
+			wait := refreshRetry
+			if err := c.refresh(); err != nil {
+				log.Printf("JWKS refresh failed: %v", err)
+			} else {
+				c.mu.RLock()
+				wait = time.Until(c.expiresAt) - refreshAhead
+				c.mu.RUnlock()
+				if wait < refreshRetry {
+					wait = refreshRetry
+				}
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
 }
 
-func (c *JwksCache) GetPublicKey(kid string) (*rsa.PublicKey, error) {
+// GetPublicKey returns the key for kid. Expired keys are still served during
+// the grace period while a refresh is attempted.
+func (c *JwksCache) GetPublicKey(kid string) (PublicKey, error) {
 	if kid == "" {
-		return nil, errors.New("kid not provided")
+		return PublicKey{}, errors.New("kid not provided")
 	}
 
+	now := time.Now()
 	c.mu.RLock()
-	if time.Now().Before(c.expiresAt) {
-		if key, ok := c.publicKey[kid]; ok {
-			c.mu.RUnlock()
-			return key, nil
+	key, found := c.publicKey[kid]
+	expiresAt := c.expiresAt
+	lastAttempt := c.lastAttempt
+	c.mu.RUnlock()
+
+	switch {
+	case found && now.Before(expiresAt):
+		return key, nil
+	case found && now.Before(expiresAt.Add(staleGrace)):
+		// Stale: serve it and refresh without blocking the request.
+		if now.Sub(lastAttempt) >= refreshRetry {
+			go func() {
+				if err := c.refresh(); err != nil {
+					log.Printf("JWKS refresh failed, serving stale keys: %v", err)
+				}
+			}()
 		}
+		return key, nil
+	case now.Sub(lastAttempt) < unknownKidInterval:
+		// Unknown kid, or keys too old to serve: the JWKS was fetched very
+		// recently, so fetching again would not help.
+		return PublicKey{}, errors.New("kid not found in jwks")
 	}
-	c.mu.RUnlock()
 
 	if err := c.refresh(); err != nil {
-		return nil, err
+		return PublicKey{}, err
 	}
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	key, ok := c.publicKey[kid]
-	if !ok {
-		return nil, errors.New("kid not found in jwks")
+	key, found = c.publicKey[kid]
+	if !found || time.Now().After(c.expiresAt.Add(staleGrace)) {
+		return PublicKey{}, errors.New("kid not found in jwks")
 	}
 	return key, nil
 }
 
+// refresh fetches the JWKS, joining a fetch already in progress instead of
+// starting another one.
 func (c *JwksCache) refresh() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if time.Now().Before(c.expiresAt) {
-		return nil
+	c.fetchMu.Lock()
+	if call := c.inflight; call != nil {
+		c.fetchMu.Unlock()
+		<-call.done
+		return call.err
 	}
+	call := &jwksFetch{done: make(chan struct{})}
+	c.inflight = call
+	c.fetchMu.Unlock()
+
+	call.err = c.fetch()
+
+	c.fetchMu.Lock()
+	c.inflight = nil
+	c.fetchMu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+// fetch downloads and parses the JWKS. On failure the current keys are kept.
+func (c *JwksCache) fetch() error {
+	c.mu.Lock()
+	c.lastAttempt = time.Now()
+	c.mu.Unlock()
 
 	resp, err := c.client.Get(c.jwksURL)
 	if err != nil {
@@ -85,7 +211,7 @@ func (c *JwksCache) refresh() error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return errors.New("failed to fetch jwks")
+		return fmt.Errorf("failed to fetch jwks: status %d", resp.StatusCode)
 	}
 
 	var parsed jwksResponse
@@ -93,13 +219,13 @@ func (c *JwksCache) refresh() error {
 		return err
 	}
 
-	newKeys := make(map[string]*rsa.PublicKey, len(parsed.Keys))
+	newKeys := make(map[string]PublicKey, len(parsed.Keys))
 	for _, key := range parsed.Keys {
-		if key.Kty != "RSA" || key.N == "" || key.E == "" || key.Kid == "" {
+		if key.Kid == "" || (key.Use != "" && key.Use != "sig") {
 			continue
 		}
 
-		pub, err := rsaFromJwk(key.N, key.E)
+		pub, err := parseJwk(key)
 		if err != nil {
 			continue
 		}
@@ -110,11 +236,119 @@ func (c *JwksCache) refresh() error {
 		return errors.New("jwks has no valid keys")
 	}
 
+	c.mu.Lock()
 	c.publicKey = newKeys
-	c.expiresAt = time.Now().Add(c.ttl)
+	c.expiresAt = time.Now().Add(cacheTTL(resp.Header.Get("Cache-Control")))
+	c.mu.Unlock()
 	return nil
 }
 
+// cacheTTL reads max-age from a Cache-Control header, clamped to sensible
+// bounds, and falls back to defaultJwksTTL.
+func cacheTTL(header string) time.Duration {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return minJwksTTL
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil {
+				return defaultJwksTTL
+			}
+			ttl := time.Duration(seconds) * time.Second
+			if ttl < minJwksTTL {
+				return minJwksTTL
+			}
+			if ttl > maxJwksTTL {
+				return maxJwksTTL
+			}
+			return ttl
+		}
+	}
+	return defaultJwksTTL
+}
+
+// parseJwk decodes a signing key and works out which algorithms it accepts.
+func parseJwk(key jwkKey) (PublicKey, error) {
+	var (
+		pub     crypto.PublicKey
+		keyType = key.Kty
+		err     error
+	)
+	switch key.Kty {
+	case "RSA":
+		if key.N == "" || key.E == "" {
+			return PublicKey{}, errors.New("rsa key missing n or e")
+		}
+		pub, err = rsaFromJwk(key.N, key.E)
+	case "EC":
+		keyType += "/" + key.Crv
+		pub, err = ecdsaFromJwk(key.Crv, key.X, key.Y)
+	case "OKP":
+		keyType += "/" + key.Crv
+		pub, err = ed25519FromJwk(key.Crv, key.X)
+	default:
+		return PublicKey{}, fmt.Errorf("unsupported key type %q", key.Kty)
+	}
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	algorithms := keyAlgorithms[keyType]
+	if key.Alg != "" {
+		if !containsString(algorithms, key.Alg) {
+			return PublicKey{}, fmt.Errorf("alg %s does not match key type %s", key.Alg, keyType)
+		}
+		algorithms = []string{key.Alg}
+	}
+
+	return PublicKey{Key: pub, Algorithms: algorithms}, nil
+}
+
+func ecdsaFromJwk(crv string, xB64 string, yB64 string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(xB64)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(yB64)
+	if err != nil {
+		return nil, err
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, errors.New("invalid ec coordinate length")
+	}
+
+	// ParseUncompressedPublicKey also rejects points that are not on the curve.
+	point := append(append([]byte{4}, x...), y...)
+	return ecdsa.ParseUncompressedPublicKey(curve, point)
+}
+
+func ed25519FromJwk(crv string, xB64 string) (ed25519.PublicKey, error) {
+	if crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(xB64)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 key length")
+	}
+	return ed25519.PublicKey(x), nil
+}
+
 func rsaFromJwk(nB64 string, eB64 string) (*rsa.PublicKey, error) {
 	nBytes, err := base64.RawURLEncoding.DecodeString(nB64)
 	if err != nil {
@@ -0,0 +1,294 @@
+package supabase
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys are the private halves of the keys served by newJwksServer.
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	p256    *ecdsa.PrivateKey
+	p384    *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{rsa: rsaKey, p256: p256, p384: p384, ed25519: edKey}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJwk(kid string, key *rsa.PublicKey) jwkKey {
+	return jwkKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   b64(key.N.Bytes()),
+		E:   b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJwk(kid string, crv string, key *ecdsa.PublicKey) jwkKey {
+	point, err := key.Bytes()
+	if err != nil {
+		panic(err)
+	}
+	size := (len(point) - 1) / 2
+	return jwkKey{
+		Kty: "EC",
+		Kid: kid,
+		Use: "sig",
+		Crv: crv,
+		X:   b64(point[1 : 1+size]),
+		Y:   b64(point[1+size:]),
+	}
+}
+
+func edJwk(kid string, key ed25519.PublicKey) jwkKey {
+	return jwkKey{Kty: "OKP", Kid: kid, Use: "sig", Crv: "Ed25519", X: b64(key)}
+}
+
+// newJwksServer serves an RSA, a P-256, a P-384 and an Ed25519 signing key,
+// plus keys the cache must ignore: one marked use=enc and one whose alg does
+// not fit its key type.
+func newJwksServer(t *testing.T, keys testKeys) *httptest.Server {
+	t.Helper()
+
+	encKey := ecJwk("enc", "P-256", &keys.p256.PublicKey)
+	encKey.Use = "enc"
+	mismatched := ecJwk("mismatched", "P-256", &keys.p256.PublicKey)
+	mismatched.Alg = "RS256"
+
+	body, err := json.Marshal(jwksResponse{Keys: []jwkKey{
+		rsaJwk("rsa", &keys.rsa.PublicKey),
+		ecJwk("p256", "P-256", &keys.p256.PublicKey),
+		ecJwk("p384", "P-384", &keys.p384.PublicKey),
+		edJwk("ed25519", keys.ed25519.Public().(ed25519.PublicKey)),
+		encKey,
+		mismatched,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestValidator(jwksURL string) *TokenValidator {
+	return &TokenValidator{
+		jwks:      NewJwksCache(jwksURL),
+		issuers:   []string{"https://example.supabase.co/auth/v1"},
+		audiences: []string{"authenticated"},
+		leeway:    30 * time.Second,
+	}
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key crypto.PrivateKey) string {
+	t.Helper()
+
+	now := time.Now()
+	claims := AccessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "5b1f6f4e-0c55-4c53-9d39-0f5cf6c1b0a1",
+			Issuer:    "https://example.supabase.co/auth/v1",
+			Audience:  jwt.ClaimStrings{"authenticated"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Role: "authenticated",
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func validationReason(err error) ValidationReason {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return verr.Reason
+	}
+	return ""
+}
+
+func TestValidateAsymmetricAlgorithms(t *testing.T) {
+	keys := newTestKeys(t)
+	validator := newTestValidator(newJwksServer(t, keys).URL)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		key    crypto.PrivateKey
+	}{
+		{"RS256", jwt.SigningMethodRS256, "rsa", keys.rsa},
+		{"ES256", jwt.SigningMethodES256, "p256", keys.p256},
+		{"ES384", jwt.SigningMethodES384, "p384", keys.p384},
+		{"EdDSA", jwt.SigningMethodEdDSA, "ed25519", keys.ed25519},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := validator.Validate(signTestToken(t, tt.method, tt.kid, tt.key))
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if claims.Subject == "" {
+				t.Fatal("claims have no subject")
+			}
+		})
+	}
+}
+
+func TestValidateRejectsAlgorithmNotAllowedForKey(t *testing.T) {
+	keys := newTestKeys(t)
+	validator := newTestValidator(newJwksServer(t, keys).URL)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		key    crypto.PrivateKey
+	}{
+		{"RS256 with EC kid", jwt.SigningMethodRS256, "p256", keys.rsa},
+		{"ES384 with P-256 kid", jwt.SigningMethodES384, "p256", keys.p384},
+		{"ES256 with RSA kid", jwt.SigningMethodES256, "rsa", keys.p256},
+		{"EdDSA with EC kid", jwt.SigningMethodEdDSA, "p384", keys.ed25519},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validator.Validate(signTestToken(t, tt.method, tt.kid, tt.key))
+			if reason := validationReason(err); reason != ReasonUnsupportedAlg {
+				t.Fatalf("reason = %q, want %q (err: %v)", reason, ReasonUnsupportedAlg, err)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsBadSignature(t *testing.T) {
+	keys := newTestKeys(t)
+	validator := newTestValidator(newJwksServer(t, keys).URL)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = validator.Validate(signTestToken(t, jwt.SigningMethodES256, "p256", other))
+	if reason := validationReason(err); reason != ReasonBadSignature {
+		t.Fatalf("reason = %q, want %q (err: %v)", reason, ReasonBadSignature, err)
+	}
+}
+
+func TestJwksCacheSkipsUnusableKeys(t *testing.T) {
+	keys := newTestKeys(t)
+	validator := newTestValidator(newJwksServer(t, keys).URL)
+
+	for _, kid := range []string{"enc", "mismatched"} {
+		t.Run(kid, func(t *testing.T) {
+			_, err := validator.Validate(signTestToken(t, jwt.SigningMethodES256, kid, keys.p256))
+			if reason := validationReason(err); reason != ReasonKeyUnavailable {
+				t.Fatalf("reason = %q, want %q (err: %v)", reason, ReasonKeyUnavailable, err)
+			}
+		})
+	}
+}
+
+func TestParseJwk(t *testing.T) {
+	keys := newTestKeys(t)
+
+	withAlg := func(key jwkKey, alg string) jwkKey {
+		key.Alg = alg
+		return key
+	}
+	p256 := ecJwk("p256", "P-256", &keys.p256.PublicKey)
+	truncated := p256
+	truncated.X = truncated.X[:len(truncated.X)-4]
+	offCurve := p256
+	offCurve.Y = b64(make([]byte, 32))
+	wrongCurve := p256
+	wrongCurve.Crv = "P-521"
+
+	tests := []struct {
+		name       string
+		key        jwkKey
+		algorithms []string
+		wantErr    bool
+	}{
+		{"rsa", rsaJwk("rsa", &keys.rsa.PublicKey), []string{"RS256", "RS384", "RS512"}, false},
+		{"rsa with alg", withAlg(rsaJwk("rsa", &keys.rsa.PublicKey), "RS384"), []string{"RS384"}, false},
+		{"p256", p256, []string{"ES256"}, false},
+		{"p384", ecJwk("p384", "P-384", &keys.p384.PublicKey), []string{"ES384"}, false},
+		{"ed25519", edJwk("ed25519", keys.ed25519.Public().(ed25519.PublicKey)), []string{"EdDSA"}, false},
+		{"ec with rsa alg", withAlg(p256, "RS256"), nil, true},
+		{"p256 with ES384 alg", withAlg(p256, "ES384"), nil, true},
+		{"rsa with EdDSA alg", withAlg(rsaJwk("rsa", &keys.rsa.PublicKey), "EdDSA"), nil, true},
+		{"truncated coordinate", truncated, nil, true},
+		{"point off curve", offCurve, nil, true},
+		{"unsupported curve", wrongCurve, nil, true},
+		{"unsupported kty", jwkKey{Kty: "oct", Kid: "oct"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub, err := parseJwk(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJwk: %v", err)
+			}
+			if len(pub.Algorithms) != len(tt.algorithms) {
+				t.Fatalf("algorithms = %v, want %v", pub.Algorithms, tt.algorithms)
+			}
+			for i := range tt.algorithms {
+				if pub.Algorithms[i] != tt.algorithms[i] {
+					t.Fatalf("algorithms = %v, want %v", pub.Algorithms, tt.algorithms)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package supabase
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Authenticator assurance levels reported in the aal claim. AAL2 means the
+// session was verified with a second factor.
+const (
+	AAL1 = "aal1"
+	AAL2 = "aal2"
+)
+
+// aalRanks orders the assurance levels HasAAL knows about.
+var aalRanks = map[string]int{
+	AAL1: 1,
+	AAL2: 2,
+}
+
+const FactorTypeTOTP = "totp"
+
+// Factor statuses. A factor stays unverified until its first challenge is
+// answered.
+const (
+	FactorStatusUnverified = "unverified"
+	FactorStatusVerified   = "verified"
+)
+
+// EnrollFactorRequest starts enrolling a new factor for the signed-in user.
+type EnrollFactorRequest struct {
+	FactorType   string `json:"factor_type"`
+	FriendlyName string `json:"friendly_name,omitempty"`
+	Issuer       string `json:"issuer,omitempty"`
+}
+
+// TOTPEnrollment is what an authenticator app needs to register the factor.
+// QRCode is an SVG data URI encoding URI.
+type TOTPEnrollment struct {
+	QRCode string `json:"qr_code"`
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Factor is an enrolled (not necessarily verified) MFA factor.
+type Factor struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	FriendlyName string          `json:"friendly_name,omitempty"`
+	Status       string          `json:"status,omitempty"`
+	TOTP         *TOTPEnrollment `json:"totp,omitempty"`
+}
+
+// Challenge must be answered with a code through VerifyFactor before
+// ExpiresAt (unix seconds).
+type Challenge struct {
+	ID        string `json:"id"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// EnrollFactor registers an unverified factor. It becomes active once the
+// first challenge for it is verified.
+func (c *Client) EnrollFactor(ctx context.Context, accessToken string, req EnrollFactorRequest) (Factor, error) {
+	var factor Factor
+	// Supabase Auth endpoint: /auth/v1/factors
+	err := c.doJSON(ctx, http.MethodPost, "/auth/v1/factors", accessToken, nil, req, &factor, nil)
+	if err != nil {
+		return Factor{}, err
+	}
+	return factor, nil
+}
+
+// ListFactors returns every factor of the user that owns accessToken,
+// verified or not.
+func (c *Client) ListFactors(ctx context.Context, accessToken string) ([]Factor, error) {
+	var user struct {
+		Factors []Factor `json:"factors"`
+	}
+	// Supabase Auth endpoint: /auth/v1/user
+	err := c.doJSON(ctx, http.MethodGet, "/auth/v1/user", accessToken, nil, nil, &user, nil)
+	if err != nil {
+		return nil, err
+	}
+	return user.Factors, nil
+}
+
+func (c *Client) ChallengeFactor(ctx context.Context, accessToken string, factorID string) (Challenge, error) {
+	var challenge Challenge
+	// Supabase Auth endpoint: /auth/v1/factors/{id}/challenge
+	err := c.doJSON(ctx, http.MethodPost, "/auth/v1/factors/"+url.PathEscape(factorID)+"/challenge", accessToken, nil, nil, &challenge, nil)
+	if err != nil {
+		return Challenge{}, err
+	}
+	return challenge, nil
+}
+
+// VerifyFactor answers a challenge. On success Supabase issues a new session
+// whose access token carries aal2.
+func (c *Client) VerifyFactor(ctx context.Context, accessToken string, factorID string, challengeID string, code string) (AuthResponse, error) {
+	var response AuthResponse
+	// Supabase Auth endpoint: /auth/v1/factors/{id}/verify
+	payload := map[string]string{
+		"challenge_id": challengeID,
+		"code":         code,
+	}
+	err := c.doJSON(ctx, http.MethodPost, "/auth/v1/factors/"+url.PathEscape(factorID)+"/verify", accessToken, nil, payload, &response, nil)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	return response, nil
+}
+
+// UnenrollFactor removes a factor. Supabase only allows removing a verified
+// factor from an aal2 session; unverified ones can be removed at aal1.
+func (c *Client) UnenrollFactor(ctx context.Context, accessToken string, factorID string) error {
+	// Supabase Auth endpoint: /auth/v1/factors/{id}
+	return c.doJSON(ctx, http.MethodDelete, "/auth/v1/factors/"+url.PathEscape(factorID), accessToken, nil, nil, nil, nil)
+}
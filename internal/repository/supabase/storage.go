@@ -0,0 +1,77 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// UploadObject stores data at path in bucket through the Storage API. The
+// request is made with the user's accessToken, so the bucket's storage
+// policies decide whether it is allowed. With upsert an existing object is
+// replaced.
+func (c *Client) UploadObject(ctx context.Context, accessToken string, bucket string, path string, contentType string, data []byte, upsert bool) error {
+	// Supabase Storage endpoint: /storage/v1/object/{bucket}/{path}
+	headers := map[string]string{
+		"Content-Type":  contentType,
+		"Cache-Control": "max-age=3600",
+		"x-upsert":      strconv.FormatBool(upsert),
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, objectPath("/storage/v1/object/", bucket, path), accessToken, nil, bytes.NewReader(data), headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, b)
+	}
+	return nil
+}
+
+// PublicObjectURL is the URL of an object in a public bucket. It does not
+// check that the object exists.
+func (c *Client) PublicObjectURL(bucket string, path string) string {
+	return c.projectURL + objectPath("/storage/v1/object/public/", bucket, path)
+}
+
+// CreateSignedObjectURL returns a URL granting read access to an object in a
+// private bucket for expiresIn seconds. A non-empty download makes Storage
+// serve the object as an attachment saved under that filename.
+func (c *Client) CreateSignedObjectURL(ctx context.Context, accessToken string, bucket string, path string, expiresIn int, download string) (string, error) {
+	// Supabase Storage endpoint: /storage/v1/object/sign/{bucket}/{path}
+	var response struct {
+		SignedURL string `json:"signedURL"`
+	}
+	payload := map[string]int{"expiresIn": expiresIn}
+	err := c.doJSON(ctx, http.MethodPost, objectPath("/storage/v1/object/sign/", bucket, path), accessToken, nil, payload, &response, nil)
+	if err != nil {
+		return "", err
+	}
+	// signedURL is relative to the Storage API root.
+	signed, err := url.Parse(c.projectURL + "/storage/v1" + response.SignedURL)
+	if err != nil {
+		return "", err
+	}
+	if download != "" {
+		q := signed.Query()
+		q.Set("download", download)
+		signed.RawQuery = q.Encode()
+	}
+	return signed.String(), nil
+}
+
+// objectPath escapes each segment of an object path under prefix.
+func objectPath(prefix string, bucket string, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return prefix + url.PathEscape(bucket) + "/" + strings.Join(segments, "/")
+}
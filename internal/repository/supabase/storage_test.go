@@ -0,0 +1,128 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/l-fraga2811/back-sable/internal/config"
+)
+
+func newStorageTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient(&config.Config{SupabaseURL: srv.URL + "/", SupabaseKey: "anon-key"})
+}
+
+func TestUploadObject(t *testing.T) {
+	for _, upsert := range []bool{true, false} {
+		var (
+			method, path, upsertHeader, contentType, auth string
+			body                                          []byte
+		)
+		client := newStorageTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			path = r.URL.EscapedPath()
+			upsertHeader = r.Header.Get("x-upsert")
+			contentType = r.Header.Get("Content-Type")
+			auth = r.Header.Get("Authorization")
+			body, _ = io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(`{"Key":"avatars/x"}`))
+		})
+
+		err := client.UploadObject(context.Background(), "user-token", "avatars", "user 1/a#b?.png", "image/png", []byte("data"), upsert)
+		if err != nil {
+			t.Fatalf("UploadObject: %v", err)
+		}
+
+		if method != http.MethodPost {
+			t.Errorf("method = %s, want POST", method)
+		}
+		if want := "/storage/v1/object/avatars/user%201/a%23b%3F.png"; path != want {
+			t.Errorf("path = %s, want %s", path, want)
+		}
+		if want := map[bool]string{true: "true", false: "false"}[upsert]; upsertHeader != want {
+			t.Errorf("x-upsert = %q, want %q", upsertHeader, want)
+		}
+		if contentType != "image/png" {
+			t.Errorf("Content-Type = %q, want image/png", contentType)
+		}
+		if auth != "Bearer user-token" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if string(body) != "data" {
+			t.Errorf("body = %q", body)
+		}
+	}
+}
+
+func TestUploadObjectError(t *testing.T) {
+	client := newStorageTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"statusCode":"403","error":"Unauthorized","message":"new row violates row-level security policy"}`))
+	})
+
+	err := client.UploadObject(context.Background(), "user-token", "avatars", "a.png", "image/png", []byte("data"), true)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if apiErr.Status != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", apiErr.Status)
+	}
+}
+
+func TestCreateSignedObjectURL(t *testing.T) {
+	var (
+		path    string
+		payload map[string]int
+	)
+	client := newStorageTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_, _ = w.Write([]byte(`{"signedURL":"/object/sign/avatars/u/avatar-256.png?token=abc"}`))
+	})
+
+	signed, err := client.CreateSignedObjectURL(context.Background(), "user-token", "avatars", "u/avatar-256.png", 3600, "")
+	if err != nil {
+		t.Fatalf("CreateSignedObjectURL: %v", err)
+	}
+
+	if want := "/storage/v1/object/sign/avatars/u/avatar-256.png"; path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	if payload["expiresIn"] != 3600 {
+		t.Errorf("expiresIn = %d, want 3600", payload["expiresIn"])
+	}
+	if want := client.projectURL + "/storage/v1/object/sign/avatars/u/avatar-256.png?token=abc"; signed != want {
+		t.Errorf("signed URL = %s, want %s", signed, want)
+	}
+}
+
+func TestCreateSignedObjectURLDownload(t *testing.T) {
+	client := newStorageTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"signedURL":"/object/sign/attachments/items/1/2?token=abc"}`))
+	})
+
+	signed, err := client.CreateSignedObjectURL(context.Background(), "service-key", "attachments", "items/1/2", 300, "recibo de março.pdf")
+	if err != nil {
+		t.Fatalf("CreateSignedObjectURL: %v", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Query().Get("token"); got != "abc" {
+		t.Errorf("token = %q, want abc", got)
+	}
+	if got := u.Query().Get("download"); got != "recibo de março.pdf" {
+		t.Errorf("download = %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package supabase
+
+import "fmt"
+
+// ValidationReason says why TokenValidator rejected a token. Reasons are
+// stable identifiers meant for logs and metrics, not for API responses.
+type ValidationReason string
+
+const (
+	ReasonMalformed        ValidationReason = "malformed"
+	ReasonUnsupportedAlg   ValidationReason = "unsupported_alg"
+	ReasonMissingKid       ValidationReason = "missing_kid"
+	ReasonKeyUnavailable   ValidationReason = "key_unavailable"
+	ReasonBadSignature     ValidationReason = "bad_signature"
+	ReasonMissingClaim     ValidationReason = "missing_claim"
+	ReasonExpired          ValidationReason = "expired"
+	ReasonNotYetValid      ValidationReason = "not_yet_valid"
+	ReasonIssuedInFuture   ValidationReason = "issued_in_future"
+	ReasonIssuerMismatch   ValidationReason = "issuer_mismatch"
+	ReasonAudienceMismatch ValidationReason = "audience_mismatch"
+	ReasonRoleNotAllowed   ValidationReason = "role_not_allowed"
+	ReasonMisconfigured    ValidationReason = "misconfigured"
+)
+
+// ValidationError is returned by TokenValidator.Validate for every rejected
+// token. Err, when set, is the underlying error from parsing or key lookup.
+type ValidationError struct {
+	Reason ValidationReason
+	Detail string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	msg := "token rejected: " + string(e.Reason)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func rejectToken(reason ValidationReason, detail string, err error) *ValidationError {
+	return &ValidationError{Reason: reason, Detail: detail, Err: err}
+}
@@ -1,9 +1,11 @@
 package supabase
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"log"
 	"strings"
 	"time"
 
@@ -14,20 +16,82 @@ import (
 type TokenValidator struct {
 	jwks      *JwksCache
 	jwtSecret []byte
+	issuers   []string
+	audiences []string
+	leeway    time.Duration
+}
+
+// userTokenRoles are the role claims accepted on user routes. anon and
+// service_role keys are signed with the same secret but are not user
+// sessions.
+var userTokenRoles = map[string]bool{
+	"authenticated": true,
 }
 
 func NewTokenValidator(cfg *config.Config) *TokenValidator {
+	if len(cfg.JwtIssuers) == 0 {
+		log.Println("Warning: no JWT issuer configured, access token iss is not checked")
+	}
 	return &TokenValidator{
 		jwks:      NewJwksCache(cfg.JwksURL),
 		jwtSecret: []byte(cfg.JwtSecret),
+		issuers:   cfg.JwtIssuers,
+		audiences: cfg.JwtAudiences,
+		leeway:    cfg.JwtLeeway,
 	}
 }
 
+// StartKeyRefresh keeps the JWKS keys fresh in the background until ctx is
+// cancelled.
+func (v *TokenValidator) StartKeyRefresh(ctx context.Context) {
+	v.jwks.Start(ctx)
+}
+
 type AccessTokenClaims struct {
 	jwt.RegisteredClaims
 	Email        string                 `json:"email"`
 	Role         string                 `json:"role"`
+	SessionID    string                 `json:"session_id"`
 	UserMetadata map[string]interface{} `json:"user_metadata"`
+	// AppMetadata is only writable with the service role key, so it is where
+	// roles and permissions are granted.
+	AppMetadata map[string]interface{} `json:"app_metadata"`
+	// AAL is the authenticator assurance level of the session (AAL1 or AAL2)
+	// and AMR lists the methods the user authenticated with, oldest first.
+	AAL string     `json:"aal"`
+	AMR []AMREntry `json:"amr"`
+}
+
+// AMREntry is one authentication method recorded in the amr claim, such as
+// "password", "otp", "oauth" or "totp". Timestamp is in unix seconds.
+type AMREntry struct {
+	Method    string `json:"method"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// HasAAL reports whether the session meets at least the given assurance
+// level. Tokens without an aal claim are treated as AAL1; unknown levels, in
+// the claim or in level, never match.
+func (c AccessTokenClaims) HasAAL(level string) bool {
+	current := c.AAL
+	if current == "" {
+		current = AAL1
+	}
+	have, ok := aalRanks[current]
+	if !ok {
+		return false
+	}
+	need, ok := aalRanks[level]
+	return ok && have >= need
+}
+
+// RevocationKey identifies the session a token belongs to, falling back to
+// the token's own jti when Supabase did not include a session_id.
+func (c AccessTokenClaims) RevocationKey() string {
+	if c.SessionID != "" {
+		return c.SessionID
+	}
+	return c.ID
 }
 
 func (c AccessTokenClaims) Username() string {
@@ -45,6 +109,8 @@ func (c AccessTokenClaims) Username() string {
 	return username
 }
 
+// Validate verifies the token's signature and claims. Every failure is a
+// *ValidationError whose Reason can be logged.
 func (v *TokenValidator) Validate(tokenString string) (AccessTokenClaims, error) {
 	alg, kid, err := tokenHeader(tokenString)
 	if err != nil {
@@ -52,52 +118,110 @@ func (v *TokenValidator) Validate(tokenString string) (AccessTokenClaims, error)
 	}
 
 	claims := AccessTokenClaims{}
-	var parsed *jwt.Token
+	options := []jwt.ParserOption{
+		jwt.WithLeeway(v.leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	}
 
+	var parsed *jwt.Token
 	if alg == "HS256" {
 		if len(v.jwtSecret) == 0 {
-			return AccessTokenClaims{}, errors.New("SUPABASE_JWT_SECRET not defined")
+			return AccessTokenClaims{}, rejectToken(ReasonMisconfigured, "SUPABASE_JWT_SECRET not defined", nil)
 		}
 		parsed, err = jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
 			return v.jwtSecret, nil
-		}, jwt.WithValidMethods([]string{"HS256"}))
+		}, append(options, jwt.WithValidMethods([]string{"HS256"}))...)
 	} else {
+		// The key decides which algorithms are acceptable, so an RSA key can
+		// never be used to verify an ES256 token or the other way round.
+		key, keyErr := v.jwks.GetPublicKey(kid)
+		if keyErr != nil {
+			return AccessTokenClaims{}, rejectToken(ReasonKeyUnavailable, kid, keyErr)
+		}
+		if !containsString(key.Algorithms, alg) {
+			return AccessTokenClaims{}, rejectToken(ReasonUnsupportedAlg, alg+" not allowed for key "+kid, nil)
+		}
 		parsed, err = jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-			key, err := v.jwks.GetPublicKey(kid)
-			if err != nil {
-				return nil, err
-			}
-			return key, nil
-		}, jwt.WithValidMethods([]string{"RS256"}))
+			return key.Key, nil
+		}, append(options, jwt.WithValidMethods(key.Algorithms))...)
 	}
 
 	if err != nil {
-		return AccessTokenClaims{}, err
+		return AccessTokenClaims{}, parseErrorReason(err)
 	}
 	if parsed == nil || !parsed.Valid {
-		return AccessTokenClaims{}, errors.New("invalid token")
+		return AccessTokenClaims{}, rejectToken(ReasonBadSignature, "", nil)
 	}
 
-	if claims.ExpiresAt != nil && time.Now().After(claims.ExpiresAt.Time) {
-		return AccessTokenClaims{}, errors.New("token expired")
+	if claims.IssuedAt == nil {
+		return AccessTokenClaims{}, rejectToken(ReasonMissingClaim, "iat", nil)
 	}
-
 	if claims.Subject == "" {
-		return AccessTokenClaims{}, errors.New("sub missing")
+		return AccessTokenClaims{}, rejectToken(ReasonMissingClaim, "sub", nil)
+	}
+	if len(v.issuers) > 0 && !containsString(v.issuers, claims.Issuer) {
+		return AccessTokenClaims{}, rejectToken(ReasonIssuerMismatch, claims.Issuer, nil)
+	}
+	if len(v.audiences) > 0 && !containsAny(v.audiences, claims.Audience) {
+		return AccessTokenClaims{}, rejectToken(ReasonAudienceMismatch, strings.Join(claims.Audience, ","), nil)
+	}
+	if !userTokenRoles[claims.Role] {
+		return AccessTokenClaims{}, rejectToken(ReasonRoleNotAllowed, claims.Role, nil)
 	}
 
 	return claims, nil
 }
 
+// parseErrorReason maps errors from jwt.ParseWithClaims to a typed reason.
+func parseErrorReason(err error) *ValidationError {
+	var reason ValidationReason
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		reason = ReasonExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		reason = ReasonNotYetValid
+	case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		reason = ReasonIssuedInFuture
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		reason = ReasonMissingClaim
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		reason = ReasonBadSignature
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		reason = ReasonKeyUnavailable
+	default:
+		reason = ReasonMalformed
+	}
+	return rejectToken(reason, "", err)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(allowed []string, values []string) bool {
+	for _, value := range values {
+		if containsString(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func tokenHeader(tokenString string) (string, string, error) {
 	parts := strings.Split(tokenString, ".")
 	if len(parts) != 3 {
-		return "", "", errors.New("malformed token")
+		return "", "", rejectToken(ReasonMalformed, "expected three segments", nil)
 	}
 
 	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
 	if err != nil {
-		return "", "", err
+		return "", "", rejectToken(ReasonMalformed, "header", err)
 	}
 
 	var header struct {
@@ -105,13 +229,16 @@ func tokenHeader(tokenString string) (string, string, error) {
 		Alg string `json:"alg"`
 	}
 	if err := json.Unmarshal(headerBytes, &header); err != nil {
-		return "", "", err
+		return "", "", rejectToken(ReasonMalformed, "header", err)
 	}
 	if header.Alg == "" {
-		return "", "", errors.New("alg missing")
+		return "", "", rejectToken(ReasonUnsupportedAlg, "alg missing", nil)
+	}
+	if header.Alg != "HS256" && !containsString(AsymmetricAlgorithms, header.Alg) {
+		return "", "", rejectToken(ReasonUnsupportedAlg, header.Alg, nil)
 	}
 	if header.Alg != "HS256" && header.Kid == "" {
-		return "", "", errors.New("kid missing")
+		return "", "", rejectToken(ReasonMissingKid, "", nil)
 	}
 
 	return header.Alg, header.Kid, nil
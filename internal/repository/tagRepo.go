@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/l-fraga2811/back-sable/internal/models"
+)
+
+// ErrTagNameTaken is returned by Create and Update when the user already has
+// a tag with the same name, compared case-insensitively.
+var ErrTagNameTaken = errors.New("tag name already taken")
+
+type TagRepository interface {
+	Create(tag *models.Tag) error
+	GetByID(id string) (*models.Tag, error)
+	ListByUser(userID string) ([]models.Tag, error)
+	// GetByIDs returns the tags among ids that belong to userID.
+	GetByIDs(userID string, ids []string) ([]models.Tag, error)
+	// NameTaken reports whether userID already has a tag with this name,
+	// compared case-insensitively, other than excludeID.
+	NameTaken(userID string, name string, excludeID string) (bool, error)
+	Update(tag *models.Tag) error
+	Delete(id string) error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/l-fraga2811/back-sable/internal/models"
+	"gorm.io/gorm"
+)
+
+// pgUniqueViolation is the SQLSTATE Postgres reports for duplicate keys.
+const pgUniqueViolation = "23505"
+
+type tagRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewTagRepositoryGorm(db *gorm.DB) TagRepository {
+	return &tagRepositoryGorm{db: db}
+}
+
+func (r *tagRepositoryGorm) Create(tag *models.Tag) error {
+	return tagWriteError(r.db.Create(tag).Error)
+}
+
+func (r *tagRepositoryGorm) GetByID(id string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.Where("id = ?", id).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepositoryGorm) ListByUser(userID string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&tags).Error
+	return tags, err
+}
+
+func (r *tagRepositoryGorm) GetByIDs(userID string, ids []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	err := r.db.Where("user_id = ? AND id IN ?", userID, ids).Find(&tags).Error
+	return tags, err
+}
+
+func (r *tagRepositoryGorm) NameTaken(userID string, name string, excludeID string) (bool, error) {
+	query := r.db.Model(&models.Tag{}).Where("user_id = ? AND lower(name) = lower(?)", userID, name)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *tagRepositoryGorm) Update(tag *models.Tag) error {
+	return tagWriteError(r.db.Save(tag).Error)
+}
+
+// tagWriteError maps a violation of the unique (user_id, lower(name)) index,
+// which catches races NameTaken misses, to ErrTagNameTaken.
+func tagWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrTagNameTaken
+	}
+	return err
+}
+
+func (r *tagRepositoryGorm) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM item_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, "id = ?", id).Error
+	})
+}
@@ -2,32 +2,140 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v3"
 	"github.com/l-fraga2811/back-sable/internal/handlers"
 	"github.com/l-fraga2811/back-sable/internal/middleware"
+	"github.com/l-fraga2811/back-sable/internal/repository"
 	"github.com/l-fraga2811/back-sable/internal/repository/supabase"
 )
 
-func SetupRoutes(app *fiber.App, tokenValidator *supabase.TokenValidator, itemHandler *handlers.ItemHandler, authHandler *handlers.AuthHandler, healthHandler *handlers.HealthHandler) {
+// Dependencies are the handlers and stores SetupRoutes wires into the app.
+// Auth routes use the global handlers set up by handlers.InitAuthHandlers.
+type Dependencies struct {
+	TokenValidator *supabase.TokenValidator
+	Revocations    repository.TokenRevocationStore
+	APIKeys        repository.APIKeyRepository
+	Profiles       repository.ProfileRepository
+	// LocalBlobs, when set, serves signed attachment downloads from disk.
+	LocalBlobs *repository.LocalBlobStore
+
+	ItemHandler   *handlers.ItemHandler
+	TagHandler    *handlers.TagHandler
+	APIKeyHandler *handlers.APIKeyHandler
+	HealthHandler *handlers.HealthHandler
+}
+
+func SetupRoutes(app *fiber.App, deps Dependencies) {
+	itemHandler, tagHandler := deps.ItemHandler, deps.TagHandler
+	apiKeyHandler, healthHandler := deps.APIKeyHandler, deps.HealthHandler
+
 	api := app.Group("/api")
+	authMiddleware := middleware.SupabaseAuthMiddleware(deps.TokenValidator, deps.Revocations)
+	apiAuth := middleware.AuthMiddleware(deps.TokenValidator, deps.Revocations, deps.APIKeys)
+	provisionProfile := middleware.ProvisionProfile(deps.Profiles)
 
 	// Auth routes - usando funções globais
 	auth := api.Group("/auth")
 	auth.Post("/signin", handlers.SignIn)
 	auth.Post("/signup", handlers.SignUp)
-	auth.Get("/profile", middleware.SupabaseAuthMiddleware(tokenValidator), handlers.GetProfile)
+	auth.Post("/refresh", handlers.RefreshSession)
+	auth.Post("/otp", handlers.RequestOTP)
+	auth.Post("/verify", handlers.VerifyOTP)
+	// Every start stores state server-side until it expires, so cap how
+	// fast a single client can create it. Clients are told apart by c.IP(),
+	// which is only the real client address behind a load balancer listed
+	// in TRUSTED_PROXIES; otherwise all users share the balancer's bucket.
+	auth.Get("/oauth/google", middleware.RateLimitByIP(10, time.Minute), handlers.GoogleOAuthStart)
+	auth.Get("/oauth/google/callback", handlers.GoogleOAuthCallback)
+	auth.Get("/profile", authMiddleware, provisionProfile, handlers.GetProfile)
+	auth.Post("/logout", authMiddleware, handlers.SignOut)
+	auth.Post("/recover", handlers.RecoverPassword)
+	auth.Post("/password", authMiddleware, handlers.ChangePassword)
+
+	// Profile routes. Edits are mirrored to Supabase with the caller's
+	// token, so API keys cannot be used here.
+	profile := api.Group("/profile", authMiddleware, provisionProfile)
+	profile.Put("/", handlers.UpdateProfile)
+	profile.Patch("/", handlers.PatchProfile)
+	profile.Post("/avatar", handlers.UploadAvatar)
 
-	// Protected routes
+	// MFA routes. Removing a verified factor needs an aal2 session so a
+	// stolen password alone cannot switch MFA off; UnenrollMFA checks that.
+	requireAAL2 := middleware.RequireAAL(supabase.AAL2)
+	mfa := auth.Group("/mfa", authMiddleware)
+	mfa.Post("/factors", handlers.EnrollMFAFactor)
+	mfa.Post("/factors/:id/challenge", handlers.ChallengeMFAFactor)
+	mfa.Post("/factors/:id/verify", handlers.VerifyMFAFactor)
+	mfa.Delete("/factors/:id", handlers.UnenrollMFAFactor)
+
+	// Protected routes accept API keys as well as sessions; API keys are
+	// limited to the resources their scopes cover.
 	protected := api.Group("/")
-	protected.Use(middleware.SupabaseAuthMiddleware(tokenValidator))
+	protected.Use(apiAuth, provisionProfile)
+
+	// API key management needs an interactive session
+	keys := protected.Group("/keys", middleware.RequireSessionAuth)
+	keys.Get("/", apiKeyHandler.GetAll)
+	keys.Post("/", apiKeyHandler.Create)
+	keys.Delete("/:id", apiKeyHandler.Revoke)
 
 	// Item routes
-	items := protected.Group("/items")
+	items := protected.Group("/items", middleware.RequireScopes("items"))
 	items.Get("/", itemHandler.GetAll)
 	items.Post("/", itemHandler.Create)
+	items.Post("/batch", itemHandler.Batch)
+	items.Get("/export", itemHandler.Export)
+	items.Post("/import", itemHandler.Import)
+	items.Get("/search", itemHandler.Search)
+	items.Get("/trash", itemHandler.Trash)
+	items.Get("/shared-with-me", itemHandler.SharedWithMe)
 	items.Get("/:id", itemHandler.GetByID)
 	items.Put("/:id", itemHandler.Update)
+	items.Patch("/:id", itemHandler.Patch)
 	items.Delete("/:id", itemHandler.Delete)
+	items.Post("/:id/restore", itemHandler.Restore)
+	items.Get("/:id/history", itemHandler.History)
+	items.Post("/:id/revert/:revision", itemHandler.Revert)
+	items.Get("/:id/shares", itemHandler.ListShares)
+	items.Post("/:id/shares", itemHandler.Share)
+	items.Delete("/:id/shares/:userId", itemHandler.RevokeShare)
+	items.Post("/:id/tags", tagHandler.AttachToItem)
+	items.Delete("/:id/tags/:tagId", tagHandler.DetachFromItem)
+	items.Get("/:id/attachments", itemHandler.ListAttachments)
+	items.Post("/:id/attachments", itemHandler.UploadAttachment)
+	items.Get("/:id/attachments/:attachmentId", itemHandler.DownloadAttachment)
+	items.Delete("/:id/attachments/:attachmentId", itemHandler.DeleteAttachment)
+
+	// Tag routes
+	tags := protected.Group("/tags", middleware.RequireScopes("tags"))
+	tags.Get("/", tagHandler.GetAll)
+	tags.Post("/", tagHandler.Create)
+	tags.Get("/:id", tagHandler.GetByID)
+	tags.Put("/:id", tagHandler.Update)
+	tags.Delete("/:id", tagHandler.Delete)
+
+	// Admin routes: manage any user's items. Admins must hold an aal2
+	// session; the item handlers are shared with the regular routes and
+	// ElevatedItemAccess lets them honour the admin's permissions.
+	admin := protected.Group("/admin", middleware.RequireSessionAuth, requireAAL2, middleware.RequireRole(middleware.RoleAdmin))
+	adminItems := admin.Group("/items", handlers.ElevatedItemAccess)
+	readAny := middleware.RequirePermission(middleware.PermissionItemsReadAny)
+	writeAny := middleware.RequirePermission(middleware.PermissionItemsWriteAny)
+	deleteAny := middleware.RequirePermission(middleware.PermissionItemsDeleteAny)
+	adminItems.Get("/", readAny, itemHandler.AdminList)
+	adminItems.Get("/:id", readAny, itemHandler.GetByID)
+	adminItems.Get("/:id/history", readAny, itemHandler.History)
+	adminItems.Put("/:id", writeAny, itemHandler.Update)
+	adminItems.Patch("/:id", writeAny, itemHandler.Patch)
+	adminItems.Delete("/:id", deleteAny, itemHandler.Delete)
+	adminItems.Post("/:id/restore", writeAny, deleteAny, itemHandler.Restore)
+
+	// Signed downloads of attachments kept on disk (development only)
+	if deps.LocalBlobs != nil {
+		app.Get(repository.LocalBlobPath+"*", handlers.ServeLocalBlob(deps.LocalBlobs))
+	}
 
 	// Health check
 	app.Get("/health", healthHandler.Check)